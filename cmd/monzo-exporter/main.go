@@ -0,0 +1,75 @@
+// Command monzo-exporter serves Monzo account and pot balances as Prometheus
+// metrics. The OAuth client is configured through the usual MONZO_CLIENT_ID,
+// MONZO_CLIENT_SECRET and MONZO_CLIENT_CONFIDENTIAL environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/prommonzo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func main() {
+	listen := flag.String("listen", ":9771", "address to serve /metrics on")
+	interval := flag.Duration("interval", time.Minute, "how often to refresh from Monzo")
+	tokenFile := flag.String("token-file", "monzo-token.json", "file holding the OAuth token")
+	login := flag.Bool("login", false, "log in through the browser when no token is stored")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := monzo.NewFileTokenStore(*tokenFile)
+
+	if _, err := store.Load(); err == monzo.ErrNoToken && *login {
+		user, err := monzo.LoopbackLogin(ctx, monzo.LoopbackConfig{PKCE: !monzo.Confidential})
+		if err != nil {
+			log.Fatalf("login failed: %v", err)
+		}
+
+		if err := store.Save(user); err != nil {
+			log.Fatalf("unable to save token: %v", err)
+		}
+	} else if err != nil {
+		log.Fatalf("unable to load token from %s: %v (run with -login)", *tokenFile, err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	exporter, err := prommonzo.NewExporter(prommonzo.TokenSourceClient(monzo.NewTokenSource(store)), registry)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exporter.OnError = func(stage string, err error) {
+		log.Printf("refresh %s: %v", stage, err)
+	}
+
+	go exporter.Run(ctx, *interval)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	server := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("serving metrics on %s/metrics", *listen)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
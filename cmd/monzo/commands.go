@@ -0,0 +1,522 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurparit/go-monzo/export"
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+const timeFormat = time.RFC3339
+
+func runLogin(a *app, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ContinueOnError)
+	pkce := flags.Bool("pkce", !monzo.Confidential, "use PKCE, required for public OAuth clients")
+	if _, err := parse(flags, args, 0, commands["login"].usage); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	user, err := monzo.LoopbackLogin(ctx, monzo.LoopbackConfig{PKCE: *pkce, Output: os.Stderr})
+	if err != nil {
+		return err
+	}
+
+	if err := a.store.Save(user); err != nil {
+		return err
+	}
+
+	a.out.message("Logged in as %s; token saved to %s", user.UserID, a.store.Path)
+
+	return nil
+}
+
+func runLogout(a *app, args []string) error {
+	if _, err := parse(flag.NewFlagSet("logout", flag.ContinueOnError), args, 0, commands["logout"].usage); err != nil {
+		return err
+	}
+
+	user, err := a.store.Load()
+	if err != nil {
+		return err
+	}
+
+	err = monzo.New(user.TokenType, user.AccessToken).LogoutAndForget(a.store)
+	if err != nil && err != monzo.ErrTokenInvalid {
+		return err
+	}
+
+	a.out.message("Logged out")
+
+	return nil
+}
+
+func runWhoAmI(a *app, args []string) error {
+	if _, err := parse(flag.NewFlagSet("whoami", flag.ContinueOnError), args, 0, commands["whoami"].usage); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	user, err := a.store.Load()
+	if err != nil {
+		return err
+	}
+
+	info, err := client.TokenInfo(user)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"user", "client", "authenticated", "expires", "can refresh"}}
+	t.add(info.UserID, info.ClientID, strconv.FormatBool(info.Authenticated), info.ExpiryDate.Format(timeFormat), strconv.FormatBool(info.CanRefresh))
+
+	return a.out.print(info, t)
+}
+
+func runAccounts(a *app, args []string) error {
+	if _, err := parse(flag.NewFlagSet("accounts", flag.ContinueOnError), args, 0, commands["accounts"].usage); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Accounts()
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"id", "description", "created"}}
+	for _, account := range result.Accounts {
+		t.add(account.ID, account.Description, account.Created.Format(timeFormat))
+	}
+
+	return a.out.print(result.Accounts, t)
+}
+
+func runBalance(a *app, args []string) error {
+	flags := flag.NewFlagSet("balance", flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+	if _, err := parse(flags, args, 0, commands["balance"].usage); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	balance, err := client.Balance(accountID)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"account", "balance", "total balance", "spend today", "currency"}}
+	t.add(accountID, balance.Money().Major(), balance.TotalMoney().Major(), balance.SpendTodayMoney().Major(), balance.Currency)
+
+	return a.out.print(balance, t)
+}
+
+func runPots(a *app, args []string) error {
+	flags := flag.NewFlagSet("pots", flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+	if _, err := parse(flags, args, 0, commands["pots"].usage); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	pots, err := client.AccountPots(accountID)
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(pots, potTable(pots))
+}
+
+func runPot(a *app, args []string) error {
+	if len(args) == 0 || (args[0] != "deposit" && args[0] != "withdraw") {
+		return fmt.Errorf("usage: monzo %s", commands["pot"].usage)
+	}
+
+	action := args[0]
+
+	flags := flag.NewFlagSet("pot "+action, flag.ContinueOnError)
+	account := flags.String("account", "", "account to move money from or to, defaults to the current account")
+	positional, err := parse(flags, args[1:], 2, commands["pot"].usage)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	pot, err := transfer(client, accountID, action, positional[0], positional[1])
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(pot, potTable([]model.Pot{pot}))
+}
+
+// transfer deposits into or withdraws from the pot named by nameOrID. The
+// amount defaults to the pot's currency and may not be in any other.
+func transfer(client monzo.API, accountID string, action string, nameOrID string, text string) (model.Pot, error) {
+	pot, err := findPot(client, accountID, nameOrID)
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	amount, err := model.ParseMoney(text, pot.Currency)
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	if amount.Currency != pot.Currency {
+		return model.Pot{}, fmt.Errorf("amount is in %s but pot %s holds %s", amount.Currency, pot.Name, pot.Currency)
+	}
+
+	if action == "deposit" {
+		return client.DepositMoney(pot, accountID, amount)
+	}
+
+	return client.WithdrawMoney(pot, accountID, amount)
+}
+
+func runTransactions(a *app, args []string) error {
+	flags := flag.NewFlagSet("transactions", flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+	since := flags.String("since", "", "RFC 3339 time or transaction ID to list from")
+	before := flags.String("before", "", "RFC 3339 time to list up to")
+	limit := flags.Int("limit", 0, "maximum number of transactions, up to 100")
+	if _, err := parse(flags, args, 0, commands["transactions"].usage); err != nil {
+		return err
+	}
+
+	params := monzo.TransactionParams{Limit: *limit}
+
+	if *since != "" {
+		if parsed, err := time.Parse(timeFormat, *since); err == nil {
+			params.Since = parsed
+		} else {
+			params.SinceID = *since
+		}
+	}
+
+	if *before != "" {
+		parsed, err := time.Parse(timeFormat, *before)
+		if err != nil {
+			return fmt.Errorf("invalid -before: %v", err)
+		}
+
+		params.Before = parsed
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	transactions, err := client.Transactions(accountID, params)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"id", "created", "description", "category", "amount", "currency"}}
+	for _, transaction := range transactions {
+		t.add(transaction.TransactionID, transaction.Created.Format(timeFormat), transaction.Description,
+			transaction.Category, transaction.Money().Major(), transaction.Currency)
+	}
+
+	return a.out.print(transactions, t)
+}
+
+func runWebhooks(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: monzo %s", commands["webhooks"].usage)
+	}
+
+	action := args[0]
+
+	flags := flag.NewFlagSet("webhooks "+action, flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+
+	positional := map[string]int{"list": 0, "add": 1, "rm": 1}
+	count, ok := positional[action]
+	if !ok {
+		return fmt.Errorf("usage: monzo %s", commands["webhooks"].usage)
+	}
+
+	rest, err := parse(flags, args[1:], count, commands["webhooks"].usage)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	if action == "rm" {
+		if err := client.DeleteWebhook(rest[0]); err != nil {
+			return err
+		}
+
+		a.out.message("Deleted webhook %s", rest[0])
+
+		return nil
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	var webhooks []model.Webhook
+	if action == "add" {
+		webhook, err := client.RegisterWebhookURL(accountID, rest[0])
+		if err != nil {
+			return err
+		}
+
+		webhooks = []model.Webhook{webhook}
+	} else {
+		webhooks, err = client.Webhooks(accountID)
+		if err != nil {
+			return err
+		}
+	}
+
+	t := table{headers: []string{"id", "account", "url"}}
+	for _, webhook := range webhooks {
+		t.add(webhook.ID, webhook.AccountID, webhook.URL)
+	}
+
+	return a.out.print(webhooks, t)
+}
+
+func runFeed(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "post" {
+		return fmt.Errorf("usage: monzo %s", commands["feed"].usage)
+	}
+
+	flags := flag.NewFlagSet("feed post", flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+	title := flags.String("title", "", "item title")
+	image := flags.String("image", "", "image URL")
+	body := flags.String("body", "", "item body")
+	link := flags.String("url", "", "URL opened when the item is tapped")
+	if _, err := parse(flags, args[1:], 0, commands["feed"].usage); err != nil {
+		return err
+	}
+
+	item := monzo.NewFeedItem(*title, *image).WithBody(*body).WithURL(*link)
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	if err := client.PostFeedItem(accountID, item); err != nil {
+		return err
+	}
+
+	a.out.message("Posted %q to %s", *title, accountID)
+
+	return nil
+}
+
+// findPot matches a pot of the account by ID first and then by name.
+func findPot(client monzo.API, accountID string, nameOrID string) (model.Pot, error) {
+	pots, err := client.AccountPots(accountID)
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	for _, pot := range pots {
+		if pot.ID == nameOrID && !pot.Deleted {
+			return pot, nil
+		}
+	}
+
+	pot, err := model.Monzo{Pots: livePots(pots)}.ByName(nameOrID)
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	return pot, nil
+}
+
+func livePots(pots []model.Pot) []model.Pot {
+	var live []model.Pot
+	for _, pot := range pots {
+		if !pot.Deleted {
+			live = append(live, pot)
+		}
+	}
+
+	return live
+}
+
+func potTable(pots []model.Pot) table {
+	t := table{headers: []string{"id", "name", "balance", "currency", "deleted"}}
+	for _, pot := range pots {
+		t.add(pot.ID, pot.Name, pot.Money().Major(), pot.Currency, strconv.FormatBool(pot.Deleted))
+	}
+
+	return t
+}
+
+func runExport(a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	account := flags.String("account", "", "account ID, defaults to the current account")
+	since := flags.String("since", "", "RFC 3339 time to export from")
+	before := flags.String("before", "", "RFC 3339 time to export up to")
+	format := flags.String("format", "csv", "export format: csv, jsonl, ofx or qif")
+	columns := flags.String("columns", strings.Join(export.DefaultColumns, ","), "CSV columns")
+	output := flags.String("o", "", "file to write to, defaults to standard output")
+	if _, err := parse(flags, args, 0, commands["export"].usage); err != nil {
+		return err
+	}
+
+	rangeStart, err := parseTime("since", *since)
+	if err != nil {
+		return err
+	}
+
+	rangeEnd, err := parseTime("before", *before)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	accountID, err := a.accountID(client, *account)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	writer, err := exportWriter(client, accountID, *format, *columns, rangeStart, rangeEnd, out)
+	if err != nil {
+		return err
+	}
+
+	count, err := export.Export(export.NewPages(client, accountID, rangeStart, rangeEnd), writer)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		a.out.message("Exported %d transactions to %s", count, *output)
+	}
+
+	return nil
+}
+
+// exportWriter returns the writer for format. OFX and QIF need a statement
+// for the account and range, so it is fetched here.
+func exportWriter(client monzo.API, accountID string, format string, columns string, start time.Time, end time.Time, out io.Writer) (export.Writer, error) {
+	switch format {
+	case "csv":
+		selected, err := export.CSVColumns(strings.Split(columns, ",")...)
+		if err != nil {
+			return nil, err
+		}
+
+		return export.NewCSVWriter(out, selected...), nil
+	case "jsonl":
+		return export.NewJSONLinesWriter(out), nil
+	case "ofx", "qif":
+		statement, err := export.NewStatement(client, accountID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		if format == "ofx" {
+			return export.NewOFXWriter(out, statement), nil
+		}
+
+		return export.NewQIFWriter(out, statement), nil
+	}
+
+	return nil, fmt.Errorf("unknown export format %q, use csv, jsonl, ofx or qif", format)
+}
+
+// parseTime parses the value of an optional RFC 3339 flag.
+func parseTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(timeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s: %v", name, err)
+	}
+
+	return parsed, nil
+}
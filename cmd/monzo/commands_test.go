@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/export"
+	"github.com/gurparit/go-monzo/model"
+)
+
+func TestFindPot(t *testing.T) {
+	server, client := fakeMonzo()
+	defer server.Close()
+
+	account := server.AddAccount("Current", 1000, "GBP")
+	holiday := server.AddPot(account.ID, "Holiday", 500)
+	deleted := server.AddPot(account.ID, "Savings", 0)
+	server.DeletePot(deleted.ID)
+
+	pot, err := findPot(client, account.ID, holiday.ID)
+	IsEqual(t, "by id error", nil, err)
+	IsEqual(t, "by id", holiday.ID, pot.ID)
+
+	pot, err = findPot(client, account.ID, "Holiday")
+	IsEqual(t, "by name error", nil, err)
+	IsEqual(t, "by name", holiday.ID, pot.ID)
+
+	_, err = findPot(client, account.ID, deleted.ID)
+	IsEqual(t, "deleted by id", true, err != nil)
+
+	_, err = findPot(client, account.ID, "Savings")
+	IsEqual(t, "deleted by name", true, err != nil)
+}
+
+func TestTransfer(t *testing.T) {
+	server, client := fakeMonzo()
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	server.AddPot(account.ID, "Holiday", 0)
+
+	pot, err := transfer(client, account.ID, "deposit", "Holiday", "12.50")
+	IsEqual(t, "deposit error", nil, err)
+	IsEqual(t, "deposit", int64(1250), pot.Balance)
+
+	pot, err = transfer(client, account.ID, "withdraw", "Holiday", "£2.50")
+	IsEqual(t, "withdraw error", nil, err)
+	IsEqual(t, "withdraw", int64(1000), pot.Balance)
+}
+
+func TestTransferCurrencyMismatchFail(t *testing.T) {
+	server, client := fakeMonzo()
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	holiday := server.AddPot(account.ID, "Holiday", 0)
+
+	_, err := transfer(client, account.ID, "deposit", "Holiday", "500 JPY")
+	IsEqual(t, "yen error", "amount is in JPY but pot Holiday holds GBP", err.Error())
+
+	_, err = transfer(client, account.ID, "deposit", "Holiday", "$10")
+	IsEqual(t, "dollar error", "amount is in USD but pot Holiday holds GBP", err.Error())
+
+	pots, err := client.AccountPots(account.ID)
+	IsEqual(t, "pots error", nil, err)
+	IsEqual(t, "pot", holiday.ID, pots[0].ID)
+	IsEqual(t, "pot balance", int64(0), pots[0].Balance)
+}
+
+func TestExportWriter(t *testing.T) {
+	server, client := fakeMonzo()
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server.AddTransaction(model.TransactionData{TransactionID: "tx_1", AccountID: account.ID, Amount: -250, Currency: "GBP", Created: created, Description: "Coffee"})
+
+	tests := []struct {
+		format   string
+		columns  string
+		contains string
+	}{
+		{"csv", "id,amount", "id,amount\ntx_1,-2.50\n"},
+		{"jsonl", "", `"id":"tx_1"`},
+		{"ofx", "", "<FITID>tx_1</FITID>"},
+		{"qif", "", "!Type:Bank\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		writer, err := exportWriter(client, account.ID, test.format, test.columns, time.Time{}, time.Time{}, &out)
+		IsEqual(t, test.format+" writer error", nil, err)
+
+		_, err = export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), writer)
+		IsEqual(t, test.format+" export error", nil, err)
+		IsEqual(t, test.format, true, strings.Contains(out.String(), test.contains))
+	}
+}
+
+func TestExportWriterFail(t *testing.T) {
+	_, err := exportWriter(nil, "acc_1", "xlsx", "", time.Time{}, time.Time{}, io.Discard)
+	IsEqual(t, "format error", `unknown export format "xlsx", use csv, jsonl, ofx or qif`, err.Error())
+
+	_, err = exportWriter(nil, "acc_1", "csv", "id,nope", time.Time{}, time.Time{}, io.Discard)
+	IsEqual(t, "columns error", true, err != nil)
+}
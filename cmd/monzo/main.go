@@ -0,0 +1,159 @@
+// Command monzo is a command-line client for everyday Monzo operations.
+//
+//	monzo [-config file] [-output table|json|csv] <command> [arguments]
+//
+// Run "monzo login" once; the token is kept in the config file and refreshed
+// automatically where the OAuth client allows it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+type app struct {
+	store *monzo.FileTokenStore
+	out   printer
+}
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+// commands is filled in by init because the commands refer back to it for
+// their usage text.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"login":        {"login [-pkce]", runLogin},
+		"logout":       {"logout", runLogout},
+		"whoami":       {"whoami", runWhoAmI},
+		"accounts":     {"accounts", runAccounts},
+		"balance":      {"balance [-account id]", runBalance},
+		"pots":         {"pots [-account id]", runPots},
+		"pot":          {"pot deposit|withdraw [-account id] <pot name or id> <amount>", runPot},
+		"transactions": {"transactions [-account id] [-since time|id] [-before time] [-limit n]", runTransactions},
+		"export":       {"export [-account id] [-since time] [-before time] [-format csv|jsonl|ofx|qif] [-columns a,b] [-o file]", runExport},
+		"webhooks":     {"webhooks list|add|rm [-account id] [url|id]", runWebhooks},
+		"feed":         {"feed post -title t -image url [-body b] [-url u] [-account id]", runFeed},
+	}
+}
+
+func main() {
+	config := flag.String("config", defaultConfigPath(), "file holding the OAuth token")
+	format := flag.String("output", formatTable, "output format: table, json or csv")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	out, err := newPrinter(*format, os.Stdout)
+	if err != nil {
+		fail(err)
+	}
+
+	name := flag.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "monzo: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	a := &app{store: monzo.NewFileTokenStore(*config), out: out}
+	if err := cmd.run(a, flag.Args()[1:]); err != nil {
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: monzo [-config file] [-output table|json|csv] <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "monzo: %v\n", err)
+	if err == monzo.ErrLoginRequired || err == monzo.ErrNoToken {
+		fmt.Fprintln(os.Stderr, "run \"monzo login\" to log in")
+	}
+
+	os.Exit(1)
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("MONZO_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "monzo-token.json"
+	}
+
+	return filepath.Join(dir, "monzo", "token.json")
+}
+
+// client returns an API client with a token from the config file, refreshed
+// and saved back first if it is about to expire.
+func (a *app) client() (monzo.Monzo, error) {
+	return monzo.NewTokenSource(a.store).Client()
+}
+
+// accountID returns id, or the current account's ID when id is empty.
+func (a *app) accountID(client monzo.Monzo, id string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+
+	account, err := client.CurrentAccount()
+	if err != nil {
+		return "", err
+	}
+
+	return account.ID, nil
+}
+
+// parse parses args for a subcommand and checks the number of positional
+// arguments left over.
+func parse(flags *flag.FlagSet, args []string, positional int, usage string) ([]string, error) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monzo %s\n", usage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != positional {
+		flags.Usage()
+		return nil, fmt.Errorf("%s: expected %d argument(s), got %d", strings.Fields(usage)[0], positional, flags.NArg())
+	}
+
+	return flags.Args(), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+func IsEqual(t *testing.T, key string, expected interface{}, actual interface{}) {
+	if !reflect.DeepEqual(expected, actual) {
+		t.Logf("for %s;", key)
+		t.Logf("expected %s;", expected)
+		t.Logf("actual: %s;", actual)
+		t.FailNow()
+	}
+}
+
+func fakeMonzo() (*monzotest.Server, monzo.Monzo) {
+	server := monzotest.NewServer()
+	server.Install()
+
+	user := server.IssueToken()
+
+	return server, monzo.New(user.TokenType, user.AccessToken)
+}
+
+func TestParse(t *testing.T) {
+	flags := flag.NewFlagSet("pot", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	account := flags.String("account", "", "")
+
+	rest, err := parse(flags, []string{"-account", "acc_1", "Holiday", "10"}, 2, "pot deposit <pot> <amount>")
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "account", "acc_1", *account)
+	IsEqual(t, "positional", []string{"Holiday", "10"}, rest)
+}
+
+func TestParseArgumentCountFail(t *testing.T) {
+	flags := flag.NewFlagSet("pot", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	_, err := parse(flags, []string{"Holiday"}, 2, "pot deposit <pot> <amount>")
+	IsEqual(t, "error", "pot: expected 2 argument(s), got 1", err.Error())
+}
+
+func TestParseUnknownFlagFail(t *testing.T) {
+	flags := flag.NewFlagSet("pots", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	_, err := parse(flags, []string{"-nope"}, 0, "pots")
+	IsEqual(t, "error", true, err != nil)
+}
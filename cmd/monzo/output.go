@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return printer{format: format, out: out}, nil
+	}
+
+	return printer{}, fmt.Errorf("unknown output format %q, use table, json or csv", format)
+}
+
+// print writes value as JSON, or t as a table or CSV. JSON uses the API
+// models directly so nothing is lost to formatting.
+func (p printer) print(value interface{}, t table) error {
+	switch p.format {
+	case formatJSON:
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case formatCSV:
+		writer := csv.NewWriter(p.out)
+		writer.Write(t.headers)
+		writer.WriteAll(t.rows)
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(t.headers, "\t")))
+	for _, row := range t.rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+func (p printer) message(format string, args ...interface{}) {
+	if p.format == formatTable {
+		fmt.Fprintf(p.out, format+"\n", args...)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+func TestPrinterPrint(t *testing.T) {
+	pots := []model.Pot{{ID: "pot_1", Name: "Holiday, 2024", Balance: 1250, Currency: "GBP"}}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{formatTable, "ID     NAME           BALANCE  CURRENCY  DELETED\npot_1  Holiday, 2024  12.50    GBP       false\n"},
+		{formatCSV, "id,name,balance,currency,deleted\npot_1,\"Holiday, 2024\",12.50,GBP,false\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		p, err := newPrinter(test.format, &out)
+		IsEqual(t, test.format+" printer error", nil, err)
+
+		err = p.print(pots, potTable(pots))
+		IsEqual(t, test.format+" print error", nil, err)
+		IsEqual(t, test.format, test.expected, out.String())
+	}
+
+	var out bytes.Buffer
+	p, _ := newPrinter(formatJSON, &out)
+	err := p.print(pots, potTable(pots))
+	IsEqual(t, "json print error", nil, err)
+	IsEqual(t, "json id", true, strings.Contains(out.String(), `"id": "pot_1"`))
+	IsEqual(t, "json balance", true, strings.Contains(out.String(), `"balance": 1250`))
+}
+
+func TestPrinterMessageOnlyForTable(t *testing.T) {
+	var out bytes.Buffer
+	p, _ := newPrinter(formatJSON, &out)
+	p.message("Deleted webhook %s", "webhook_1")
+	IsEqual(t, "json message", "", out.String())
+
+	p, _ = newPrinter(formatTable, &out)
+	p.message("Deleted webhook %s", "webhook_1")
+	IsEqual(t, "table message", "Deleted webhook webhook_1\n", out.String())
+}
+
+func TestNewPrinterUnknownFormatFail(t *testing.T) {
+	_, err := newPrinter("xml", io.Discard)
+	IsEqual(t, "error", `unknown output format "xml", use table, json or csv`, err.Error())
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+// Column is one CSV column: a header and how to read its value from a
+// transaction.
+type Column struct {
+	Header string
+	Value  func(transaction model.TransactionData) string
+}
+
+// Columns available to CSVColumns by name. Amounts are in major units, e.g.
+// "-12.34" for £12.34 spent.
+var Columns = map[string]Column{
+	"id":                {"id", func(t model.TransactionData) string { return t.TransactionID }},
+	"account_id":        {"account_id", func(t model.TransactionData) string { return t.AccountID }},
+	"created":           {"created", func(t model.TransactionData) string { return t.Created.Format(time.RFC3339) }},
+	"date":              {"date", func(t model.TransactionData) string { return t.Created.Format("2006-01-02") }},
+	"description":       {"description", func(t model.TransactionData) string { return t.Description }},
+	"category":          {"category", func(t model.TransactionData) string { return t.Category }},
+	"amount":            {"amount", func(t model.TransactionData) string { return t.Money().Major() }},
+	"currency":          {"currency", func(t model.TransactionData) string { return t.Currency }},
+	"settled":           {"settled", func(t model.TransactionData) string { return t.Settled }},
+	"merchant":          {"merchant", func(t model.TransactionData) string { return t.Merchant.Name }},
+	"merchant_category": {"merchant_category", func(t model.TransactionData) string { return t.Merchant.Category }},
+	"decline_reason":    {"decline_reason", func(t model.TransactionData) string { return t.DeclineReason }},
+}
+
+var DefaultColumns = []string{"id", "created", "description", "amount", "currency", "category", "merchant", "merchant_category"}
+
+// CSVColumns looks up columns by name, in order.
+func CSVColumns(names ...string) ([]Column, error) {
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		column, ok := Columns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// CSVWriter writes a header row followed by one row per transaction.
+type CSVWriter struct {
+	columns []Column
+	writer  *csv.Writer
+	header  bool
+}
+
+// NewCSVWriter writes the given columns, or DefaultColumns when none are
+// given.
+func NewCSVWriter(w io.Writer, columns ...Column) *CSVWriter {
+	if len(columns) == 0 {
+		columns, _ = CSVColumns(DefaultColumns...)
+	}
+
+	return &CSVWriter{columns: columns, writer: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) Write(transaction model.TransactionData) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		row[i] = column.Value(transaction)
+	}
+
+	return c.writer.Write(row)
+}
+
+// Flush writes any buffered rows, and the header if nothing was written.
+func (c *CSVWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+
+	return c.writer.Error()
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+
+	c.header = true
+
+	headers := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		headers[i] = column.Header
+	}
+
+	return c.writer.Write(headers)
+}
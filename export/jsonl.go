@@ -0,0 +1,30 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+// JSONLinesWriter writes each transaction as one line of JSON, in the same
+// shape as the API returns it.
+type JSONLinesWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	writer := bufio.NewWriter(w)
+
+	return &JSONLinesWriter{writer: writer, encoder: json.NewEncoder(writer)}
+}
+
+func (j *JSONLinesWriter) Write(transaction model.TransactionData) error {
+	return j.encoder.Encode(transaction)
+}
+
+func (j *JSONLinesWriter) Flush() error {
+	return j.writer.Flush()
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+const (
+	ofxTimeFormat  = "20060102150405.000"
+	ofxNameLimit   = 32
+	ofxAcctIDLimit = 22
+)
+
+// OFXWriter writes an OFX 2.2 bank statement. The header is written with the
+// first transaction and the ledger balance on Flush, so transactions are
+// streamed rather than collected.
+type OFXWriter struct {
+	statement Statement
+	writer    *bufio.Writer
+	header    bool
+}
+
+func NewOFXWriter(w io.Writer, statement Statement) *OFXWriter {
+	if statement.End.IsZero() {
+		statement.End = time.Now().UTC()
+	}
+
+	if statement.BankID == "" {
+		statement.BankID = MonzoSortCode
+	}
+
+	return &OFXWriter{statement: statement, writer: bufio.NewWriter(w)}
+}
+
+// Write skips declined transactions, which never moved money.
+func (o *OFXWriter) Write(transaction model.TransactionData) error {
+	if transaction.Declined() {
+		return nil
+	}
+
+	if !o.header {
+		o.writeHeader(transaction.Created)
+	}
+
+	trnType := "CREDIT"
+	if transaction.Amount < 0 {
+		trnType = "DEBIT"
+	}
+
+	name := payee(transaction)
+	if potID := transaction.PotID(); potID != "" {
+		trnType = "XFER"
+		name = o.statement.potName(potID)
+	}
+
+	o.line("<STMTTRN>")
+	o.element("TRNTYPE", trnType)
+	o.element("DTPOSTED", ofxTime(posted(transaction)))
+	o.element("DTUSER", ofxTime(transaction.Created))
+	o.element("TRNAMT", transaction.Money().Major())
+	o.element("FITID", transaction.TransactionID)
+	o.element("NAME", truncate(name, ofxNameLimit))
+	if memo := strings.TrimSpace(transaction.Description + " " + transaction.Category); memo != "" {
+		o.element("MEMO", memo)
+	}
+	o.line("</STMTTRN>")
+
+	return nil
+}
+
+// Flush closes the transaction list, writes the ledger balance and ends the
+// document.
+func (o *OFXWriter) Flush() error {
+	if !o.header {
+		o.writeHeader(o.statement.End)
+	}
+
+	balance := model.NewMoney(o.statement.Balance.Balance, o.currency())
+
+	o.line("</BANKTRANLIST>")
+	o.line("<LEDGERBAL>")
+	o.element("BALAMT", balance.Major())
+	o.element("DTASOF", ofxTime(o.statement.End))
+	o.line("</LEDGERBAL>")
+	o.line("</STMTRS>")
+	o.line("</STMTTRNRS>")
+	o.line("</BANKMSGSRSV1>")
+	o.line("</OFX>")
+
+	return o.writer.Flush()
+}
+
+func (o *OFXWriter) writeHeader(first time.Time) {
+	o.header = true
+
+	start := o.statement.Start
+	if start.IsZero() {
+		start = first
+	}
+
+	o.line(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	o.line(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`)
+	o.line("<OFX>")
+	o.line("<SIGNONMSGSRSV1>")
+	o.line("<SONRS>")
+	o.status()
+	o.element("DTSERVER", ofxTime(o.statement.End))
+	o.element("LANGUAGE", "ENG")
+	o.line("</SONRS>")
+	o.line("</SIGNONMSGSRSV1>")
+	o.line("<BANKMSGSRSV1>")
+	o.line("<STMTTRNRS>")
+	o.element("TRNUID", "0")
+	o.status()
+	o.line("<STMTRS>")
+	o.element("CURDEF", o.currency())
+	o.line("<BANKACCTFROM>")
+	o.element("BANKID", o.statement.BankID)
+	o.element("ACCTID", truncate(o.statement.accountNumber(), ofxAcctIDLimit))
+	o.element("ACCTTYPE", "CHECKING")
+	o.line("</BANKACCTFROM>")
+	o.line("<BANKTRANLIST>")
+	o.element("DTSTART", ofxTime(start))
+	o.element("DTEND", ofxTime(o.statement.End))
+}
+
+func (o *OFXWriter) status() {
+	o.line("<STATUS>")
+	o.element("CODE", "0")
+	o.element("SEVERITY", "INFO")
+	o.line("</STATUS>")
+}
+
+func (o *OFXWriter) currency() string {
+	if o.statement.Currency != "" {
+		return strings.ToUpper(o.statement.Currency)
+	}
+
+	return strings.ToUpper(o.statement.Balance.Currency)
+}
+
+func (o *OFXWriter) line(text string) {
+	o.writer.WriteString(text)
+	o.writer.WriteByte('\n')
+}
+
+func (o *OFXWriter) element(name string, value string) {
+	fmt.Fprintf(o.writer, "<%s>", name)
+	xml.EscapeText(o.writer, []byte(value))
+	fmt.Fprintf(o.writer, "</%s>\n", name)
+}
+
+// ofxTime formats t in UTC with the time zone spelled out, as OFX expects.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeFormat) + "[0:GMT]"
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit])
+}
@@ -0,0 +1,128 @@
+// Package export streams transactions out of Monzo into file formats used by
+// spreadsheets and accounting software.
+package export
+
+import (
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+// Pages iterates over every transaction of an account between Since and
+// Before, fetching a page at a time so only one page is held in memory.
+//
+//	pages := export.NewPages(client, accountID, since, before)
+//	for pages.Next() {
+//		transaction := pages.Transaction()
+//	}
+//	if err := pages.Err(); err != nil { ... }
+type Pages struct {
+	API       monzo.API
+	AccountID string
+	Since     time.Time
+	Before    time.Time
+	PageSize  int
+
+	page    []model.TransactionData
+	index   int
+	lastID  string
+	started bool
+	done    bool
+	err     error
+}
+
+func NewPages(api monzo.API, accountID string, since time.Time, before time.Time) *Pages {
+	return &Pages{API: api, AccountID: accountID, Since: since, Before: before, PageSize: monzo.MaxTransactionsLimit}
+}
+
+// Next advances to the next transaction, fetching the next page when the
+// current one is used up. It returns false at the end or on error.
+func (p *Pages) Next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.page) {
+		if p.done {
+			return false
+		}
+
+		if !p.fetch() {
+			return false
+		}
+	}
+
+	p.lastID = p.page[p.index].TransactionID
+
+	return true
+}
+
+func (p *Pages) fetch() bool {
+	params := monzo.TransactionParams{Before: p.Before, Limit: p.limit()}
+	if p.started {
+		params.SinceID = p.lastID
+	} else {
+		params.Since = p.Since
+	}
+
+	page, err := p.API.Transactions(p.AccountID, params)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	p.started = true
+	p.page = page
+	p.index = 0
+
+	// A short page is the last one; a full page may be followed by more.
+	if len(page) == 0 || len(page) < p.limit() {
+		p.done = true
+	}
+
+	return len(page) > 0
+}
+
+func (p *Pages) limit() int {
+	if p.PageSize <= 0 || p.PageSize > monzo.MaxTransactionsLimit {
+		return monzo.MaxTransactionsLimit
+	}
+
+	return p.PageSize
+}
+
+func (p *Pages) Transaction() model.TransactionData {
+	return p.page[p.index]
+}
+
+func (p *Pages) Err() error {
+	return p.err
+}
+
+// Writer is implemented by each export format.
+type Writer interface {
+	Write(transaction model.TransactionData) error
+	Flush() error
+}
+
+// Export writes every transaction from pages to w and returns how many were
+// written.
+func Export(pages *Pages, w Writer) (int, error) {
+	count := 0
+	for pages.Next() {
+		if err := w.Write(pages.Transaction()); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	if err := pages.Err(); err != nil {
+		w.Flush()
+		return count, err
+	}
+
+	return count, w.Flush()
+}
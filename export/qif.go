@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+// QIFDateFormat is day first to match UK accounting software; set it to
+// "01/02/2006" for packages that expect US dates.
+var QIFDateFormat = "02/01/2006"
+
+// QIFWriter writes a QIF bank register. Pot transfers are written with the
+// pot as the transfer account, e.g. "L[Holiday]", so they balance against
+// the pot when it is imported as its own account.
+type QIFWriter struct {
+	statement Statement
+	writer    *bufio.Writer
+	header    bool
+}
+
+func NewQIFWriter(w io.Writer, statement Statement) *QIFWriter {
+	return &QIFWriter{statement: statement, writer: bufio.NewWriter(w)}
+}
+
+// Write skips declined transactions, which never moved money.
+func (q *QIFWriter) Write(transaction model.TransactionData) error {
+	q.writeHeader()
+
+	if transaction.Declined() {
+		return nil
+	}
+
+	q.field('D', posted(transaction).Format(QIFDateFormat))
+	q.field('T', transaction.Money().Major())
+
+	if _, ok := transaction.SettledAt(); ok {
+		q.field('C', "*")
+	}
+
+	if potID := transaction.PotID(); potID != "" {
+		name := q.statement.potName(potID)
+		q.field('P', name)
+		q.field('L', "["+name+"]")
+	} else {
+		q.field('P', payee(transaction))
+		q.field('M', transaction.Description)
+		if transaction.Category != "" {
+			q.field('L', transaction.Category)
+		}
+	}
+
+	q.writer.WriteString("^\n")
+
+	return nil
+}
+
+func (q *QIFWriter) Flush() error {
+	q.writeHeader()
+
+	return q.writer.Flush()
+}
+
+func (q *QIFWriter) writeHeader() {
+	if q.header {
+		return
+	}
+
+	q.header = true
+	q.writer.WriteString("!Type:Bank\n")
+}
+
+// field writes one QIF line. Values cannot span lines, so line breaks are
+// folded into spaces.
+func (q *QIFWriter) field(code byte, value string) {
+	q.writer.WriteByte(code)
+	q.writer.WriteString(strings.Join(strings.Fields(value), " "))
+	q.writer.WriteByte('\n')
+}
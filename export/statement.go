@@ -0,0 +1,127 @@
+package export
+
+import (
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+// MonzoSortCode is used as the bank ID in OFX statements when the account
+// has no sort code.
+const MonzoSortCode = "040004"
+
+// Statement describes the account and period around the transactions in an
+// OFX or QIF export.
+type Statement struct {
+	AccountID string
+	// AccountNumber identifies the account to accounting software, falling
+	// back to AccountID when empty. OFX keeps at most 22 characters of it.
+	AccountNumber string
+	BankID        string
+	Currency      string
+	Start         time.Time
+	End           time.Time
+	// Balance is the ledger balance as of End.
+	Balance model.Balance
+	// PotNames maps pot IDs to names so pot transfers read as transfers to
+	// a named account.
+	PotNames map[string]string
+}
+
+// NewStatement fetches the account details, the balance and the account's
+// pots for a statement from start to end. A zero end means now; an earlier end has the
+// transactions since then taken off the current balance.
+func NewStatement(api monzo.API, accountID string, start time.Time, end time.Time) (Statement, error) {
+	now := time.Now().UTC()
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+
+	accounts, err := api.Accounts()
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement := Statement{AccountID: accountID, BankID: MonzoSortCode, Start: start, End: end}
+	for _, account := range accounts.Accounts {
+		if account.ID == accountID {
+			statement.AccountNumber = account.AccountNumber
+			if account.SortCode != "" {
+				statement.BankID = account.SortCode
+			}
+
+			break
+		}
+	}
+
+	balance, err := api.Balance(accountID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	if end.Before(now) {
+		pages := NewPages(api, accountID, end, time.Time{})
+		for pages.Next() {
+			if transaction := pages.Transaction(); !transaction.Declined() {
+				balance.Balance -= transaction.Amount
+			}
+		}
+
+		if err := pages.Err(); err != nil {
+			return Statement{}, err
+		}
+	}
+
+	pots, err := api.AccountPots(accountID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	names := make(map[string]string, len(pots))
+	for _, pot := range pots {
+		names[pot.ID] = pot.Name
+	}
+
+	statement.Currency = balance.Currency
+	statement.Balance = balance
+	statement.PotNames = names
+
+	return statement, nil
+}
+
+func (s Statement) accountNumber() string {
+	if s.AccountNumber != "" {
+		return s.AccountNumber
+	}
+
+	return s.AccountID
+}
+
+func (s Statement) potName(potID string) string {
+	if name := s.PotNames[potID]; name != "" {
+		return name
+	}
+
+	return potID
+}
+
+// payee is the merchant name where Monzo knows it, otherwise the raw
+// description.
+func payee(transaction model.TransactionData) string {
+	if transaction.Merchant.Name != "" {
+		return transaction.Merchant.Name
+	}
+
+	return transaction.Description
+}
+
+// posted is when the transaction settled, or when it was created while it
+// is pending.
+func posted(transaction model.TransactionData) time.Time {
+	if settled, ok := transaction.SettledAt(); ok {
+		return settled
+	}
+
+	return transaction.Created
+}
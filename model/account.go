@@ -3,7 +3,9 @@ package model
 import "time"
 
 type Account struct {
-	ID          string    `json:"id"`
-	Description string    `json:"description"`
-	Created     time.Time `json:"created"`
+	ID            string    `json:"id"`
+	Description   string    `json:"description"`
+	Created       time.Time `json:"created"`
+	AccountNumber string    `json:"account_number,omitempty"`
+	SortCode      string    `json:"sort_code,omitempty"`
 }
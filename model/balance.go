@@ -1,8 +1,138 @@
 package model
 
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
 type Balance struct {
-	Balance      int64  `json:"balance"`
-	TotalBalance int64  `json:"total_balance"`
-	SpendToday   int64  `json:"spend_today"`
-	Currency     string `json:"currency"`
+	Balance                         int64        `json:"balance"`
+	TotalBalance                    int64        `json:"total_balance"`
+	BalanceIncludingFlexibleSavings int64        `json:"balance_including_flexible_savings"`
+	SpendToday                      int64        `json:"spend_today"`
+	Currency                        string       `json:"currency"`
+	LocalCurrency                   string       `json:"local_currency"`
+	LocalExchangeRate               ExchangeRate `json:"local_exchange_rate"`
+	LocalSpend                      []LocalSpend `json:"local_spend"`
+}
+
+type LocalSpend struct {
+	SpendToday int64  `json:"spend_today"`
+	Currency   string `json:"currency"`
+}
+
+type SpendSummary struct {
+	Total      Money
+	ByCurrency []Money
+}
+
+// ExchangeRate accepts Monzo's local_exchange_rate, which is a number while
+// abroad and an empty string otherwise.
+type ExchangeRate float64
+
+func (rate *ExchangeRate) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*rate = 0
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return err
+	}
+
+	*rate = ExchangeRate(value)
+	return nil
+}
+
+func (rate ExchangeRate) MarshalJSON() ([]byte, error) {
+	if rate == 0 {
+		return []byte(`""`), nil
+	}
+
+	return json.Marshal(float64(rate))
+}
+
+func (balance Balance) Money() Money {
+	return NewMoney(balance.Balance, balance.Currency)
+}
+
+func (balance Balance) TotalMoney() Money {
+	return NewMoney(balance.TotalBalance, balance.Currency)
+}
+
+func (balance Balance) FlexibleSavingsMoney() Money {
+	return NewMoney(balance.BalanceIncludingFlexibleSavings, balance.Currency)
+}
+
+func (balance Balance) SpendTodayMoney() Money {
+	return NewMoney(balance.SpendToday, balance.Currency)
+}
+
+func (balance Balance) IsAbroad() bool {
+	return balance.LocalCurrency != "" && !strings.EqualFold(balance.LocalCurrency, balance.Currency)
+}
+
+// ToLocal converts an amount in the account currency into the local currency
+// using the current local exchange rate.
+func (balance Balance) ToLocal(amount Money) (Money, error) {
+	if !strings.EqualFold(amount.Currency, balance.Currency) {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	if !balance.IsAbroad() || balance.LocalExchangeRate == 0 {
+		return amount, nil
+	}
+
+	scale := math.Pow10(CurrencyExponent(balance.LocalCurrency) - CurrencyExponent(balance.Currency))
+	converted := math.Round(float64(amount.Amount) * float64(balance.LocalExchangeRate) * scale)
+	if converted > math.MaxInt64 || converted < math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(int64(converted), balance.LocalCurrency), nil
+}
+
+// SpendTodaySummary totals today's spending in the account currency and
+// breaks it down per currency spent, so trips abroad show what was spent in
+// each local currency.
+func (balance Balance) SpendTodaySummary() SpendSummary {
+	summary := SpendSummary{Total: balance.SpendTodayMoney()}
+
+	index := make(map[string]int)
+	for _, spend := range balance.LocalSpend {
+		money := NewMoney(spend.SpendToday, spend.Currency)
+		if i, ok := index[money.Currency]; ok {
+			if sum, err := summary.ByCurrency[i].Add(money); err == nil {
+				summary.ByCurrency[i] = sum
+			}
+
+			continue
+		}
+
+		index[money.Currency] = len(summary.ByCurrency)
+		summary.ByCurrency = append(summary.ByCurrency, money)
+	}
+
+	if len(summary.ByCurrency) == 0 && !summary.Total.IsZero() {
+		summary.ByCurrency = []Money{summary.Total}
+	}
+
+	return summary
+}
+
+func (summary SpendSummary) String() string {
+	if len(summary.ByCurrency) <= 1 {
+		return summary.Total.String()
+	}
+
+	parts := make([]string, 0, len(summary.ByCurrency))
+	for _, money := range summary.ByCurrency {
+		parts = append(parts, money.String())
+	}
+
+	return summary.Total.String() + " (" + strings.Join(parts, ", ") + ")"
 }
@@ -0,0 +1,290 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("cannot combine amounts in different currencies")
+	ErrAmountOverflow   = errors.New("amount overflows int64 minor units")
+)
+
+type currency struct {
+	exponent int
+	symbol   string
+}
+
+// Minor unit exponents per ISO 4217. Currencies missing from the table are
+// assumed to have two decimal places.
+var currencies = map[string]currency{
+	"AUD": {2, "A$"},
+	"BHD": {3, ""},
+	"CAD": {2, "C$"},
+	"CHF": {2, ""},
+	"CNY": {2, "¥"},
+	"CZK": {2, "Kč"},
+	"DKK": {2, "kr"},
+	"EUR": {2, "€"},
+	"GBP": {2, "£"},
+	"HKD": {2, "HK$"},
+	"HUF": {2, "Ft"},
+	"INR": {2, "₹"},
+	"ISK": {0, "kr"},
+	"JOD": {3, ""},
+	"JPY": {0, "¥"},
+	"KRW": {0, "₩"},
+	"KWD": {3, ""},
+	"NOK": {2, "kr"},
+	"NZD": {2, "NZ$"},
+	"OMR": {3, ""},
+	"PLN": {2, "zł"},
+	"SEK": {2, "kr"},
+	"THB": {2, "฿"},
+	"TND": {3, ""},
+	"TRY": {2, "₺"},
+	"USD": {2, "$"},
+	"VND": {0, "₫"},
+	"ZAR": {2, "R"},
+}
+
+func CurrencyExponent(code string) int {
+	if info, ok := currencies[strings.ToUpper(code)]; ok {
+		return info.exponent
+	}
+
+	return 2
+}
+
+func CurrencySymbol(code string) string {
+	return currencies[strings.ToUpper(code)].symbol
+}
+
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if !m.sameCurrency(other) {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(sum, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return m.Add(other.Neg())
+}
+
+func (m Money) Neg() Money {
+	return NewMoney(-m.Amount, m.Currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) sameCurrency(other Money) bool {
+	return strings.EqualFold(m.Currency, other.Currency)
+}
+
+// Major formats the amount in major units without a currency, e.g. "-12.34".
+func (m Money) Major() string {
+	exponent := CurrencyExponent(m.Currency)
+
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUint(m.Amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+func (m Money) String() string {
+	major := m.Major()
+
+	sign := ""
+	if strings.HasPrefix(major, "-") {
+		sign, major = "-", major[1:]
+	}
+
+	if symbol := CurrencySymbol(m.Currency); symbol != "" {
+		return sign + symbol + major
+	}
+
+	return fmt.Sprintf("%s%s %s", sign, major, strings.ToUpper(m.Currency))
+}
+
+// ParseMoney reads amounts as typed by users, such as "£12.34", "-5",
+// "1,250.50 EUR" or "JPY 500". A currency code or symbol in the input
+// overrides defaultCurrency; a symbol shared by several currencies must be
+// settled by defaultCurrency.
+func ParseMoney(input string, defaultCurrency string) (Money, error) {
+	text := strings.TrimSpace(input)
+	code := strings.ToUpper(defaultCurrency)
+
+	negative := false
+	if strings.HasPrefix(text, "-") {
+		negative, text = true, strings.TrimSpace(text[1:])
+	}
+
+	if fields := strings.Fields(text); len(fields) == 2 {
+		if isCurrencyCode(fields[0]) {
+			code, text = strings.ToUpper(fields[0]), fields[1]
+		} else if isCurrencyCode(fields[1]) {
+			code, text = strings.ToUpper(fields[1]), fields[0]
+		}
+	}
+
+	symbolCode, rest, err := trimSymbol(text, code)
+	if err != nil {
+		return Money{}, fmt.Errorf("ambiguous currency in amount %q: %v", input, err)
+	}
+
+	if symbolCode != "" {
+		code, text = symbolCode, rest
+	}
+
+	if strings.HasPrefix(text, "-") {
+		negative, text = !negative, text[1:]
+	}
+
+	if code == "" {
+		return Money{}, fmt.Errorf("no currency given for amount %q", input)
+	}
+
+	amount, err := parseMinorUnits(strings.Replace(text, ",", "", -1), CurrencyExponent(code))
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %v", input, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return NewMoney(amount, code), nil
+}
+
+// trimSymbol strips a leading currency symbol and returns its currency. A
+// symbol shared between currencies, such as "¥" or "kr", is only accepted
+// when preferred is one of them.
+func trimSymbol(text string, preferred string) (string, string, error) {
+	if symbol := CurrencySymbol(preferred); symbol != "" && strings.HasPrefix(text, symbol) {
+		return preferred, strings.TrimSpace(text[len(symbol):]), nil
+	}
+
+	var match string
+	var codes []string
+	for code, info := range currencies {
+		if info.symbol == "" || !strings.HasPrefix(text, info.symbol) || len(info.symbol) < len(match) {
+			continue
+		}
+
+		if len(info.symbol) > len(match) {
+			match, codes = info.symbol, nil
+		}
+
+		codes = append(codes, code)
+	}
+
+	if match == "" {
+		return "", text, nil
+	}
+
+	if len(codes) > 1 {
+		sort.Strings(codes)
+		return "", text, fmt.Errorf("%s may be any of %s", match, strings.Join(codes, ", "))
+	}
+
+	return codes[0], strings.TrimSpace(text[len(match):]), nil
+}
+
+func parseMinorUnits(text string, exponent int) (int64, error) {
+	if text == "" {
+		return 0, errors.New("missing digits")
+	}
+
+	whole, fraction := text, ""
+	if i := strings.Index(text, "."); i >= 0 {
+		whole, fraction = text[:i], text[i+1:]
+	}
+
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("at most %d decimal places allowed", exponent)
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+
+	return amount, nil
+}
+
+func isCurrencyCode(text string) bool {
+	if len(text) != 3 {
+		return false
+	}
+
+	for _, r := range text {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func absUint(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-(amount + 1)) + 1
+	}
+
+	return uint64(amount)
+}
@@ -6,10 +6,11 @@ import (
 )
 
 type Monzo struct {
-	Accounts []Account `json:"accounts"`
-	Pots     []Pot     `json:"pots"`
-	Webhooks []Webhook `json:"webhooks"`
-	Webhook  Webhook   `json:"webhook"`
+	Accounts     []Account         `json:"accounts"`
+	Pots         []Pot             `json:"pots"`
+	Webhooks     []Webhook         `json:"webhooks"`
+	Webhook      Webhook           `json:"webhook"`
+	Transactions []TransactionData `json:"transactions"`
 }
 
 func (monzo Monzo) ByName(name string) (Pot, error) {
@@ -1,9 +1,14 @@
 package model
 
 type Pot struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
-	Deleted  bool   `json:"deleted"`
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Balance          int64  `json:"balance"`
+	Currency         string `json:"currency"`
+	Deleted          bool   `json:"deleted"`
+	CurrentAccountID string `json:"current_account_id,omitempty"`
+}
+
+func (pot Pot) Money() Money {
+	return NewMoney(pot.Balance, pot.Currency)
 }
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+type AccountSnapshot struct {
+	Account Account `json:"account"`
+	Balance Balance `json:"balance"`
+	Pots    []Pot   `json:"pots"`
+	Err     error   `json:"-"`
+}
+
+type Snapshot struct {
+	Taken    time.Time         `json:"taken"`
+	Accounts []AccountSnapshot `json:"accounts"`
+}
+
+func (account AccountSnapshot) PotsTotal() []Money {
+	var totals []Money
+	for _, pot := range account.Pots {
+		if !pot.Deleted {
+			totals = addTo(totals, pot.Money())
+		}
+	}
+
+	return totals
+}
+
+// NetWorth sums account balances and active pots per currency, skipping
+// accounts that failed to load.
+func (snapshot Snapshot) NetWorth() []Money {
+	var totals []Money
+	for _, account := range snapshot.Accounts {
+		if account.Err != nil {
+			continue
+		}
+
+		totals = addTo(totals, account.Balance.Money())
+		for _, total := range account.PotsTotal() {
+			totals = addTo(totals, total)
+		}
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Currency < totals[j].Currency
+	})
+
+	return totals
+}
+
+func (snapshot Snapshot) Failed() []AccountSnapshot {
+	var failed []AccountSnapshot
+	for _, account := range snapshot.Accounts {
+		if account.Err != nil {
+			failed = append(failed, account)
+		}
+	}
+
+	return failed
+}
+
+func addTo(totals []Money, money Money) []Money {
+	for i, total := range totals {
+		if sum, err := total.Add(money); err == nil {
+			totals[i] = sum
+			return totals
+		}
+	}
+
+	return append(totals, money)
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type TokenInfo struct {
+	WhoAmI
+	ExpiryDate time.Time     `json:"expiry_date"`
+	Remaining  time.Duration `json:"remaining"`
+	CanRefresh bool          `json:"can_refresh"`
+}
+
+func (info TokenInfo) Expired() bool {
+	return !info.Authenticated || info.Remaining <= 0
+}
+
+func (info TokenInfo) ExpiresWithin(window time.Duration) bool {
+	return info.Expired() || info.Remaining <= window
+}
@@ -1,19 +1,66 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Transaction struct {
-	Type string `json:"type"`
-	Data struct {
-		TransactionID string    `json:"id"`
-		AccountID     string    `json:"account_id"`
-		Description   string    `json:"description"`
-		Category      string    `json:"category"`
-		Amount        int64     `json:"amount"`
-		Currency      string    `json:"currency"`
-		Created       time.Time `json:"created"`
-		Settled       string    `json:"settled"`
-		IsLoad        bool      `json:"is_load"`
-		Merchant      Merchant  `json:"merchant"`
-	} `json:"data"`
+	Type string          `json:"type"`
+	Data TransactionData `json:"data"`
+}
+
+type TransactionData struct {
+	TransactionID string    `json:"id"`
+	AccountID     string    `json:"account_id"`
+	Description   string    `json:"description"`
+	Category      string    `json:"category"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Created       time.Time `json:"created"`
+	Settled       string    `json:"settled"`
+	IsLoad        bool      `json:"is_load"`
+	Merchant      Merchant  `json:"merchant"`
+	DeclineReason string    `json:"decline_reason,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (transaction Transaction) Money() Money {
+	return transaction.Data.Money()
+}
+
+func (data TransactionData) Money() Money {
+	return NewMoney(data.Amount, data.Currency)
+}
+
+// PotID returns the pot money moved to or from, or "" when the transaction is
+// not a pot transfer.
+func (data TransactionData) PotID() string {
+	if id := data.Metadata["pot_id"]; id != "" {
+		return id
+	}
+
+	if strings.HasPrefix(data.Description, "pot_") {
+		return data.Description
+	}
+
+	return ""
+}
+
+// Declined reports whether the transaction was declined. Declined
+// transactions keep their amount but never move money.
+func (data TransactionData) Declined() bool {
+	return data.DeclineReason != ""
+}
+
+// SettledAt returns when the transaction settled, and false while it is
+// still pending.
+func (data TransactionData) SettledAt() (time.Time, bool) {
+	settled, err := time.Parse(time.RFC3339Nano, data.Settled)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return settled, true
 }
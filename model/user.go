@@ -1,6 +1,7 @@
 package model
 
 import (
+	"log/slog"
 	"time"
 )
 
@@ -18,3 +19,14 @@ func (user *User) UpdateExpiry() {
 	expiry := time.Second * time.Duration(user.ExpiresIn)
 	user.ExpiryDate = time.Now().Add(expiry)
 }
+
+// LogValue keeps tokens out of structured logs.
+func (user User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("user_id", user.UserID),
+		slog.String("client_id", user.ClientID),
+		slog.String("access_token", "REDACTED"),
+		slog.String("refresh_token", "REDACTED"),
+		slog.Time("expiry_date", user.ExpiryDate),
+	)
+}
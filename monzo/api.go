@@ -0,0 +1,45 @@
+package monzo
+
+import (
+	"context"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+// API is implemented by Monzo and Cached, and by monzotest.Fake for tests.
+type API interface {
+	WhoAmI() (model.WhoAmI, error)
+	TokenInfo(user model.User) (model.TokenInfo, error)
+	Logout() error
+	LogoutAndForget(store TokenStore) error
+	WaitForApproval(ctx context.Context, pollInterval time.Duration, progress func(ApprovalProgress)) error
+
+	Accounts() (model.Monzo, error)
+	CurrentAccount() (model.Account, error)
+	Balance(accountID string) (model.Balance, error)
+	Pots() (model.Monzo, error)
+	AccountPots(accountID string) ([]model.Pot, error)
+	Snapshot(ctx context.Context) (model.Snapshot, error)
+	Transactions(accountID string, params TransactionParams) ([]model.TransactionData, error)
+
+	Withdraw(sourcePotID string, destinationAccountID string, amount int64) (model.Pot, error)
+	Deposit(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error)
+	WithdrawWithDedupe(sourcePotID string, destinationAccountID string, amount int64, dedupeID string) (model.Pot, error)
+	DepositWithDedupe(targetPotID string, sourceAccountID string, amount int64, dedupeID string) (model.Pot, error)
+	WithdrawMoney(sourcePot model.Pot, destinationAccountID string, amount model.Money) (model.Pot, error)
+	DepositMoney(targetPot model.Pot, sourceAccountID string, amount model.Money) (model.Pot, error)
+
+	RegisterWebhook(accountID string) (model.Webhook, error)
+	RegisterWebhookURL(accountID string, webhookURL string) (model.Webhook, error)
+	DeleteWebhook(webhookID string) error
+	Webhooks(accountID string) ([]model.Webhook, error)
+
+	CreateFeedItem(accountID string, title string, body string, imageURL string) error
+	PostFeedItem(accountID string, item *FeedItem) error
+}
+
+var (
+	_ API = Monzo{}
+	_ API = (*Cached)(nil)
+)
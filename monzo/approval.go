@@ -0,0 +1,70 @@
+package monzo
+
+import (
+	"context"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	insufficientPermissions = "forbidden.insufficient_permissions"
+	defaultPollInterval     = 5 * time.Second
+)
+
+type ApprovalProgress struct {
+	Attempt  int
+	Elapsed  time.Duration
+	Approved bool
+	Err      error
+}
+
+func IsInsufficientPermissions(err error) bool {
+	return HasCode(err, insufficientPermissions)
+}
+
+// WaitForApproval polls Monzo until the user has approved access in the app
+// (Strong Customer Authentication) or ctx is done.
+func (m Monzo) WaitForApproval(ctx context.Context, pollInterval time.Duration, progress func(ApprovalProgress)) error {
+	return waitForApproval(ctx, m.WithContext(ctx).Accounts, pollInterval, progress)
+}
+
+func waitForApproval(ctx context.Context, accounts func() (model.Monzo, error), pollInterval time.Duration, progress func(ApprovalProgress)) error {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	started := time.Now()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		_, err := accounts()
+
+		update := ApprovalProgress{
+			Attempt:  attempt,
+			Elapsed:  time.Since(started),
+			Approved: err == nil,
+			Err:      err,
+		}
+
+		if progress != nil {
+			progress(update)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if !IsInsufficientPermissions(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "gave up waiting for access approval in the monzo app")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package monzo
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	stateBytes          = 32
+	verifierBytes       = 32
+	defaultStateTTL     = 10 * time.Minute
+	defaultStateName    = "monzo_oauth_state"
+	stateCookiePrefix   = "v2"
+	codeChallengeMethod = "S256"
+)
+
+var ErrInvalidState = errors.New("oauth state is missing, expired or does not match")
+
+// StateStore keeps the CSRF state, and the PKCE code verifier when one is
+// used, between the start of the login and the callback.
+type StateStore interface {
+	Save(w http.ResponseWriter, r *http.Request, state string, codeVerifier string) error
+	Verify(w http.ResponseWriter, r *http.Request, state string) (string, error)
+}
+
+func GenerateState() (string, error) {
+	return randomToken(stateBytes, "oauth state")
+}
+
+func GeneratePKCE() (string, string, error) {
+	verifier, err := randomToken(verifierBytes, "pkce code verifier")
+	if err != nil {
+		return "", "", err
+	}
+
+	return verifier, CodeChallenge(verifier), nil
+}
+
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomToken(size int, name string) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", errors.Wrapf(err, "unable to generate %s", name)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+type savedState struct {
+	expiry       time.Time
+	codeVerifier string
+}
+
+// MemoryStateStore keeps states in memory; the zero value is ready to use
+// with the default TTL.
+type MemoryStateStore struct {
+	TTL time.Duration
+
+	mu     sync.Mutex
+	states map[string]savedState
+}
+
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	return &MemoryStateStore{
+		TTL:    ttl,
+		states: make(map[string]savedState),
+	}
+}
+
+func (s *MemoryStateStore) Save(w http.ResponseWriter, r *http.Request, state string, codeVerifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states == nil {
+		s.states = make(map[string]savedState)
+	}
+
+	now := time.Now()
+	for saved, entry := range s.states {
+		if now.After(entry.expiry) {
+			delete(s.states, saved)
+		}
+	}
+
+	s.states[state] = savedState{
+		expiry:       now.Add(stateTTL(s.TTL)),
+		codeVerifier: codeVerifier,
+	}
+
+	return nil
+}
+
+func (s *MemoryStateStore) Verify(w http.ResponseWriter, r *http.Request, state string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.states[state]
+	delete(s.states, state)
+
+	if !ok || state == "" || time.Now().After(entry.expiry) {
+		return "", ErrInvalidState
+	}
+
+	return entry.codeVerifier, nil
+}
+
+type CookieStateStore struct {
+	Name   string
+	Key    []byte
+	TTL    time.Duration
+	Path   string
+	Secure bool
+}
+
+func NewCookieStateStore(key []byte) *CookieStateStore {
+	return &CookieStateStore{
+		Name:   defaultStateName,
+		Key:    key,
+		TTL:    defaultStateTTL,
+		Path:   "/",
+		Secure: true,
+	}
+}
+
+func (s *CookieStateStore) Save(w http.ResponseWriter, r *http.Request, state string, codeVerifier string) error {
+	if len(s.Key) == 0 {
+		return errors.New("cookie state store requires a signing key")
+	}
+
+	expiry := time.Now().Add(stateTTL(s.TTL))
+	payload := strings.Join([]string{stateCookiePrefix, state, codeVerifier, strconv.FormatInt(expiry.Unix(), 10)}, ".")
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Value:    payload + "." + s.sign(payload),
+		Path:     s.Path,
+		Expires:  expiry,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+func (s *CookieStateStore) Verify(w http.ResponseWriter, r *http.Request, state string) (string, error) {
+	cookie, err := r.Cookie(s.name())
+	if err != nil {
+		return "", ErrInvalidState
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Value:    "",
+		Path:     s.Path,
+		MaxAge:   -1,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 5 || parts[0] != stateCookiePrefix {
+		return "", ErrInvalidState
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(s.sign(payload))) {
+		return "", ErrInvalidState
+	}
+
+	expiry, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiry, 0)) {
+		return "", ErrInvalidState
+	}
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(parts[1]), []byte(state)) != 1 {
+		return "", ErrInvalidState
+	}
+
+	return parts[2], nil
+}
+
+func (s *CookieStateStore) name() string {
+	if s.Name == "" {
+		return defaultStateName
+	}
+
+	return s.Name
+}
+
+func (s *CookieStateStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func stateTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return defaultStateTTL
+	}
+
+	return ttl
+}
+
+type AuthFlow struct {
+	Store       StateStore
+	RedirectURI string
+	PKCE        bool
+	OnSuccess   func(w http.ResponseWriter, r *http.Request, user model.User)
+	OnError     func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewAuthFlow(store StateStore, onSuccess func(w http.ResponseWriter, r *http.Request, user model.User)) AuthFlow {
+	return AuthFlow{
+		Store:     store,
+		OnSuccess: onSuccess,
+	}
+}
+
+func (a AuthFlow) StartHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := GenerateState()
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+
+		authURL, err := a.begin(w, r, state)
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+}
+
+func (a AuthFlow) begin(w http.ResponseWriter, r *http.Request, state string) (string, error) {
+	var verifier, challenge string
+	if a.PKCE {
+		var err error
+		if verifier, challenge, err = GeneratePKCE(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := a.Store.Save(w, r, state, verifier); err != nil {
+		return "", err
+	}
+
+	return loginURL(state, a.redirectURI(), challenge), nil
+}
+
+func (a AuthFlow) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		verifier, err := a.Store.Verify(w, r, query.Get("state"))
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+
+		if authError := query.Get("error"); authError != "" {
+			a.fail(w, r, errors.Errorf("authorization failed: %s", authError))
+			return
+		}
+
+		code := query.Get("code")
+		if code == "" {
+			a.fail(w, r, errors.New("authorization code missing from callback"))
+			return
+		}
+
+		user, err := exchange(r.Context(), code, a.redirectURI(), verifier)
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+
+		if a.OnSuccess != nil {
+			a.OnSuccess(w, r, user)
+		}
+	})
+}
+
+func (a AuthFlow) redirectURI() string {
+	if a.RedirectURI == "" {
+		return RedirectURI
+	}
+
+	return a.RedirectURI
+}
+
+func (a AuthFlow) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if a.OnError != nil {
+		a.OnError(w, r, err)
+		return
+	}
+
+	status := http.StatusBadGateway
+	if err == ErrInvalidState {
+		status = http.StatusBadRequest
+	}
+
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,289 @@
+package monzo
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+const (
+	cacheWhoAmI      = "whoami"
+	cacheAccounts    = "accounts"
+	cacheBalance     = "balance:"
+	cachePots        = "pots"
+	cacheAccountPots = "pots:"
+)
+
+type CacheTTL struct {
+	WhoAmI   time.Duration
+	Accounts time.Duration
+	Balance  time.Duration
+	Pots     time.Duration
+}
+
+var DefaultCacheTTL = CacheTTL{
+	WhoAmI:   time.Minute,
+	Accounts: 5 * time.Minute,
+	Balance:  30 * time.Second,
+	Pots:     time.Minute,
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+type cacheCall struct {
+	wg         sync.WaitGroup
+	value      interface{}
+	err        error
+	generation int
+}
+
+// Cached wraps a client and caches its read endpoints. Concurrent identical
+// reads share one request, and pot and balance entries are dropped whenever a
+// deposit or withdrawal succeeds through the same Cached client.
+type Cached struct {
+	Monzo
+
+	ttl   CacheTTL
+	state *cacheState
+}
+
+// cacheState is shared by a Cached client and its copies from WithContext
+// and WithResponse.
+type cacheState struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	calls      map[string]*cacheCall
+	generation int
+}
+
+func NewCached(m Monzo, ttl CacheTTL) *Cached {
+	return &Cached{
+		Monzo: m,
+		ttl:   ttl,
+		state: &cacheState{
+			entries: make(map[string]cacheEntry),
+			calls:   make(map[string]*cacheCall),
+		},
+	}
+}
+
+// WithContext returns a copy sharing the cache whose requests are sent with
+// ctx. A request shared by concurrent identical reads uses the context of
+// the read that started it.
+func (c *Cached) WithContext(ctx context.Context) *Cached {
+	return &Cached{Monzo: c.Monzo.WithContext(ctx), ttl: c.ttl, state: c.state}
+}
+
+// WithResponse returns a copy sharing the cache that records the metadata of
+// each response; reads served from the cache record nothing.
+func (c *Cached) WithResponse(recorder *ResponseRecorder) *Cached {
+	return &Cached{Monzo: c.Monzo.WithResponse(recorder), ttl: c.ttl, state: c.state}
+}
+
+func (c *Cached) WhoAmI() (model.WhoAmI, error) {
+	value, err := c.load(cacheWhoAmI, c.ttl.WhoAmI, func() (interface{}, error) {
+		return c.Monzo.WhoAmI()
+	})
+	if err != nil {
+		return model.WhoAmI{}, err
+	}
+
+	return value.(model.WhoAmI), nil
+}
+
+func (c *Cached) TokenInfo(user model.User) (model.TokenInfo, error) {
+	whoami, err := c.WhoAmI()
+	if err != nil {
+		return model.TokenInfo{}, err
+	}
+
+	return tokenInfo(whoami, user), nil
+}
+
+// WaitForApproval polls through the cache; failures are never cached, and
+// the accounts seen once access is approved are.
+func (c *Cached) WaitForApproval(ctx context.Context, pollInterval time.Duration, progress func(ApprovalProgress)) error {
+	return waitForApproval(ctx, c.WithContext(ctx).Accounts, pollInterval, progress)
+}
+
+func (c *Cached) Accounts() (model.Monzo, error) {
+	value, err := c.load(cacheAccounts, c.ttl.Accounts, func() (interface{}, error) {
+		return c.Monzo.Accounts()
+	})
+	if err != nil {
+		return model.Monzo{}, err
+	}
+
+	return value.(model.Monzo), nil
+}
+
+func (c *Cached) CurrentAccount() (model.Account, error) {
+	monzo, err := c.Accounts()
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if len(monzo.Accounts) == 0 {
+		return model.Account{}, errNoAccounts
+	}
+
+	return monzo.Accounts[0], nil
+}
+
+func (c *Cached) Balance(accountID string) (model.Balance, error) {
+	value, err := c.load(cacheBalance+accountID, c.ttl.Balance, func() (interface{}, error) {
+		return c.Monzo.Balance(accountID)
+	})
+	if err != nil {
+		return model.Balance{}, err
+	}
+
+	return value.(model.Balance), nil
+}
+
+func (c *Cached) Pots() (model.Monzo, error) {
+	value, err := c.load(cachePots, c.ttl.Pots, func() (interface{}, error) {
+		return c.Monzo.Pots()
+	})
+	if err != nil {
+		return model.Monzo{}, err
+	}
+
+	return value.(model.Monzo), nil
+}
+
+func (c *Cached) AccountPots(accountID string) ([]model.Pot, error) {
+	value, err := c.load(cacheAccountPots+accountID, c.ttl.Pots, func() (interface{}, error) {
+		return c.Monzo.AccountPots(accountID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]model.Pot), nil
+}
+
+func (c *Cached) Snapshot(ctx context.Context) (model.Snapshot, error) {
+	return takeSnapshot(ctx, c.WithContext(ctx))
+}
+
+func (c *Cached) Withdraw(sourcePotID string, destinationAccountID string, amount int64) (model.Pot, error) {
+	pot, err := c.Monzo.Withdraw(sourcePotID, destinationAccountID, amount)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) Deposit(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error) {
+	pot, err := c.Monzo.Deposit(targetPotID, sourceAccountID, amount)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) WithdrawWithDedupe(sourcePotID string, destinationAccountID string, amount int64, dedupeID string) (model.Pot, error) {
+	pot, err := c.Monzo.WithdrawWithDedupe(sourcePotID, destinationAccountID, amount, dedupeID)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) DepositWithDedupe(targetPotID string, sourceAccountID string, amount int64, dedupeID string) (model.Pot, error) {
+	pot, err := c.Monzo.DepositWithDedupe(targetPotID, sourceAccountID, amount, dedupeID)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) WithdrawMoney(sourcePot model.Pot, destinationAccountID string, amount model.Money) (model.Pot, error) {
+	pot, err := c.Monzo.WithdrawMoney(sourcePot, destinationAccountID, amount)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) DepositMoney(targetPot model.Pot, sourceAccountID string, amount model.Money) (model.Pot, error) {
+	pot, err := c.Monzo.DepositMoney(targetPot, sourceAccountID, amount)
+	if err == nil {
+		c.invalidateMoney()
+	}
+
+	return pot, err
+}
+
+func (c *Cached) Invalidate() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	c.state.entries = make(map[string]cacheEntry)
+	c.state.generation++
+}
+
+func (c *Cached) invalidateMoney() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	for key := range c.state.entries {
+		if key == cachePots || strings.HasPrefix(key, cacheAccountPots) || strings.HasPrefix(key, cacheBalance) {
+			delete(c.state.entries, key)
+		}
+	}
+
+	c.state.generation++
+}
+
+func (c *Cached) load(key string, ttl time.Duration, fetch func() (interface{}, error)) (interface{}, error) {
+	c.state.mu.Lock()
+	if entry, ok := c.state.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.state.mu.Unlock()
+		return entry.value, nil
+	}
+
+	// A call started before an invalidation may return what the deposit or
+	// withdrawal changed, so only calls from the current generation are
+	// joined.
+	if call, ok := c.state.calls[key]; ok && call.generation == c.state.generation {
+		c.state.mu.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+
+	call := &cacheCall{generation: c.state.generation}
+	call.wg.Add(1)
+	c.state.calls[key] = call
+	c.state.mu.Unlock()
+
+	call.value, call.err = fetch()
+
+	// Results that raced with an invalidation may already be stale, so they
+	// are returned but not cached.
+	c.state.mu.Lock()
+	if c.state.calls[key] == call {
+		delete(c.state.calls, key)
+	}
+
+	if call.err == nil && ttl > 0 && call.generation == c.state.generation {
+		c.state.entries[key] = cacheEntry{value: call.value, expires: time.Now().Add(ttl)}
+	}
+	c.state.mu.Unlock()
+
+	call.wg.Done()
+
+	return call.value, call.err
+}
@@ -0,0 +1,169 @@
+package monzo
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+const feedItemTypeBasic = "basic"
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type FeedItem struct {
+	title           string
+	body            string
+	imageURL        string
+	url             string
+	backgroundColor string
+	titleColor      string
+	bodyColor       string
+}
+
+func NewFeedItem(title string, imageURL string) *FeedItem {
+	return &FeedItem{
+		title:    title,
+		imageURL: imageURL,
+	}
+}
+
+func (f *FeedItem) WithBody(body string) *FeedItem {
+	f.body = body
+	return f
+}
+
+func (f *FeedItem) WithURL(url string) *FeedItem {
+	f.url = url
+	return f
+}
+
+func (f *FeedItem) WithBackgroundColor(hex string) *FeedItem {
+	f.backgroundColor = hex
+	return f
+}
+
+func (f *FeedItem) WithTitleColor(hex string) *FeedItem {
+	f.titleColor = hex
+	return f
+}
+
+func (f *FeedItem) WithBodyColor(hex string) *FeedItem {
+	f.bodyColor = hex
+	return f
+}
+
+func (f *FeedItem) Title() string {
+	return f.title
+}
+
+func (f *FeedItem) Body() string {
+	return f.body
+}
+
+func (f *FeedItem) ImageURL() string {
+	return f.imageURL
+}
+
+func (f *FeedItem) URL() string {
+	return f.url
+}
+
+func (f *FeedItem) BackgroundColor() string {
+	return f.backgroundColor
+}
+
+func (f *FeedItem) TitleColor() string {
+	return f.titleColor
+}
+
+func (f *FeedItem) BodyColor() string {
+	return f.bodyColor
+}
+
+func (f *FeedItem) Validate() error {
+	if f.title == "" {
+		return errors.New("feed item title is required")
+	}
+
+	if f.imageURL == "" {
+		return errors.New("feed item image url is required")
+	}
+
+	if err := validateFeedURL("image url", f.imageURL); err != nil {
+		return err
+	}
+
+	if f.url != "" {
+		if err := validateFeedURL("url", f.url); err != nil {
+			return err
+		}
+	}
+
+	colors := []struct {
+		name  string
+		value string
+	}{
+		{"background color", f.backgroundColor},
+		{"title color", f.titleColor},
+		{"body color", f.bodyColor},
+	}
+
+	for _, color := range colors {
+		if color.value != "" && !hexColor.MatchString(color.value) {
+			return errors.Errorf("feed item %s must be a hex colour like #FFFFFF, got %q", color.name, color.value)
+		}
+	}
+
+	return nil
+}
+
+func (f *FeedItem) form(accountID string) map[string]string {
+	data := make(map[string]string)
+	data["account_id"] = accountID
+	data["type"] = feedItemTypeBasic
+	data["params[title]"] = f.title
+	data["params[image_url]"] = f.imageURL
+
+	optional := map[string]string{
+		"url":                      f.url,
+		"params[body]":             f.body,
+		"params[background_color]": f.backgroundColor,
+		"params[title_color]":      f.titleColor,
+		"params[body_color]":       f.bodyColor,
+	}
+
+	for key, value := range optional {
+		if value != "" {
+			data[key] = value
+		}
+	}
+
+	return data
+}
+
+func validateFeedURL(name string, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrapf(err, "feed item %s is invalid", name)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return errors.Errorf("feed item %s must be an absolute http(s) url, got %q", name, rawURL)
+	}
+
+	return nil
+}
+
+func (m Monzo) PostFeedItem(accountID string, item *FeedItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	if err := m.do(OpFeedItemCreate, http.MethodPost, GetURL(FeedItemCreateURL), item.form(accountID), nil); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package monzo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLoopbackHost = "127.0.0.1"
+	defaultLoopbackPath = "/callback"
+
+	loopbackSuccessPage = `<!DOCTYPE html>
+<html><body><p>Logged in to Monzo. Approve access in the Monzo app, then close this window.</p></body></html>`
+)
+
+type LoopbackConfig struct {
+	Host         string
+	Port         int
+	Path         string
+	PKCE         bool
+	Open         func(authURL string) error
+	Output       io.Writer
+	PollInterval time.Duration
+	Progress     func(ApprovalProgress)
+}
+
+type loopbackResult struct {
+	user model.User
+	err  error
+}
+
+// LoopbackLogin runs the OAuth2 login for command-line tools: it listens on a
+// temporary localhost redirect URI, waits for the magic-link callback,
+// exchanges the code and then waits for the user to approve access in the app.
+func LoopbackLogin(ctx context.Context, config LoopbackConfig) (model.User, error) {
+	host := config.Host
+	if host == "" {
+		host = defaultLoopbackHost
+	}
+
+	path := config.Path
+	if path == "" {
+		path = defaultLoopbackPath
+	}
+
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(config.Port)))
+	if err != nil {
+		return model.User{}, errors.Wrap(err, "unable to start loopback listener")
+	}
+
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), path)
+	results := make(chan loopbackResult, 1)
+
+	flow := AuthFlow{
+		Store:       NewMemoryStateStore(0),
+		RedirectURI: redirectURI,
+		PKCE:        config.PKCE,
+		OnSuccess: func(w http.ResponseWriter, r *http.Request, user model.User) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, loopbackSuccessPage)
+			deliver(results, loopbackResult{user: user})
+		},
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			deliver(results, loopbackResult{err: err})
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, flow.CallbackHandler())
+
+	// Callback requests, and so the code exchange, are cancelled with ctx.
+	server := &http.Server{Handler: mux, BaseContext: func(net.Listener) context.Context { return ctx }}
+	go server.Serve(listener)
+	defer server.Close()
+
+	state, err := GenerateState()
+	if err != nil {
+		return model.User{}, err
+	}
+
+	authURL, err := flow.begin(nil, nil, state)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if config.Open != nil {
+		if err := config.Open(authURL); err != nil {
+			fmt.Fprintf(output, "Unable to open a browser (%v).\n", err)
+			fmt.Fprintf(output, "Open this URL to log in to Monzo:\n\n%s\n\n", authURL)
+		}
+	} else {
+		fmt.Fprintf(output, "Open this URL to log in to Monzo:\n\n%s\n\n", authURL)
+	}
+
+	var result loopbackResult
+	select {
+	case <-ctx.Done():
+		return model.User{}, errors.Wrap(ctx.Err(), "gave up waiting for the monzo login callback")
+	case result = <-results:
+	}
+
+	if result.err != nil {
+		return model.User{}, result.err
+	}
+
+	fmt.Fprintln(output, "Approve access in the Monzo app to continue.")
+
+	m := New(result.user.TokenType, result.user.AccessToken)
+	if err := m.WaitForApproval(ctx, config.PollInterval, config.Progress); err != nil {
+		return model.User{}, err
+	}
+
+	return result.user, nil
+}
+
+func deliver(results chan loopbackResult, result loopbackResult) {
+	select {
+	case results <- result:
+	default:
+	}
+}
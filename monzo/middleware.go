@@ -0,0 +1,93 @@
+package monzo
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	OpTokenExchange    = "oauth2.token"
+	OpTokenRefresh     = "oauth2.refresh"
+	OpLogout           = "oauth2.logout"
+	OpWhoAmI           = "whoami"
+	OpAccountsList     = "accounts.list"
+	OpBalanceRead      = "balance.read"
+	OpPotsList         = "pots.list"
+	OpPotsDeposit      = "pots.deposit"
+	OpPotsWithdraw     = "pots.withdraw"
+	OpTransactionsList = "transactions.list"
+	OpWebhooksList     = "webhooks.list"
+	OpWebhooksRegister = "webhooks.register"
+	OpWebhooksDelete   = "webhooks.delete"
+	OpFeedItemCreate   = "feed.create"
+)
+
+// Call is a single request to Monzo as seen by middleware. Middleware may
+// modify Request, for example to add headers, before passing it on.
+type Call struct {
+	Operation string
+	Request   *http.Request
+}
+
+type Doer func(call *Call) (*http.Response, error)
+
+// Middleware wraps a Doer. The response it sees has not been read yet, so
+// middleware must not consume the body.
+type Middleware func(next Doer) Doer
+
+// WithMiddleware adds middleware to the client. The first middleware given
+// is the outermost and sees each call first.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(m *Monzo) {
+		m.middleware = append(m.middleware[:len(m.middleware):len(m.middleware)], middleware...)
+	}
+}
+
+func chain(middleware []Middleware, doer Doer) Doer {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		doer = middleware[i](doer)
+	}
+
+	return doer
+}
+
+// Logging logs one line per call with its operation, method, path, status
+// and duration. Query strings are left out.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			response, err := next(call)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				logger.Printf("monzo %s %s %s failed after %s: %v", call.Operation, call.Request.Method, call.Request.URL.Path, elapsed, err)
+			} else {
+				logger.Printf("monzo %s %s %s %d %s", call.Operation, call.Request.Method, call.Request.URL.Path, response.StatusCode, elapsed)
+			}
+
+			return response, err
+		}
+	}
+}
+
+// Timing reports the duration of every call to observe. Status is zero when
+// the request failed before a response arrived.
+func Timing(observe func(operation string, status int, duration time.Duration, err error)) Middleware {
+	return func(next Doer) Doer {
+		return func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			response, err := next(call)
+
+			status := 0
+			if response != nil {
+				status = response.StatusCode
+			}
+
+			observe(call.Operation, status, time.Since(start), err)
+
+			return response, err
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package monzo
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 
 	"strconv"
 
@@ -11,8 +13,8 @@ import (
 
 	"strings"
 
-	"github.com/gurparit/go-common/httpc"
-	"github.com/gurparit/go-common/logio"
+	"time"
+
 	"github.com/gurparit/go-common/uuid"
 	"github.com/gurparit/go-monzo/model"
 	"github.com/pkg/errors"
@@ -23,15 +25,19 @@ const (
 	monzoClientSecret = "MONZO_CLIENT_SECRET"
 	monzoRedirectURI  = "MONZO_REDIRECT_URI"
 	monzoWebhookURI   = "MONZO_WEBHOOK_URI"
+	monzoConfidential = "MONZO_CLIENT_CONFIDENTIAL"
 
 	LoginURL          = "MONZO_URL_LOGIN"
 	WhoAmIURL         = "MONZO_URL_WHOAMI"
 	Oauth2URL         = "MONZO_URL_OAUTH2"
+	LogoutURL         = "MONZO_URL_LOGOUT"
 	AccountsURL       = "MONZO_URL_ACCOUNTS"
 	BalanceURL        = "MONZO_URL_BALANCE"
 	PotsURL           = "MONZO_URL_POTS"
+	AccountPotsURL    = "MONZO_URL_ACCOUNT_POTS"
 	DepositURL        = "MONZO_URL_POTS_DEPOSIT"
 	WithdrawURL       = "MONZO_URL_POTS_WITHDRAW"
+	TransactionsURL   = "MONZO_URL_TRANSACTIONS"
 	WebhookGetURL     = "MONZO_URL_WEBHOOK"
 	WebhookCreateURL  = "MONZO_URL_WEBHOOK_CREATE"
 	WebhookDeleteURL  = "MONZO_URL_WEBHOOK_DELETE"
@@ -43,26 +49,36 @@ var (
 	ClientSecret = os.Getenv(monzoClientSecret)
 	RedirectURI  = os.Getenv(monzoRedirectURI)
 	WebhookURI   = os.Getenv(monzoWebhookURI)
+	Confidential = os.Getenv(monzoConfidential) != "false"
 )
 
 var urlMap = map[string]string{
 	LoginURL:          "https://auth.monzo.com/?client_id=%s&redirect_uri=%s&response_type=code&state=%s",
 	WhoAmIURL:         "https://api.monzo.com/ping/whoami",
 	Oauth2URL:         "https://api.monzo.com/oauth2/token",
+	LogoutURL:         "https://api.monzo.com/oauth2/logout",
 	AccountsURL:       "https://api.monzo.com/accounts?account_type=uk_retail",
 	BalanceURL:        "https://api.monzo.com/balance?account_id=%s",
 	PotsURL:           "https://api.monzo.com/pots",
+	AccountPotsURL:    "https://api.monzo.com/pots?current_account_id=%s",
 	DepositURL:        "https://api.monzo.com/pots/%s/deposit",
 	WithdrawURL:       "https://api.monzo.com/pots/%s/withdraw",
+	TransactionsURL:   "https://api.monzo.com/transactions?expand[]=merchant&account_id=%s",
 	WebhookGetURL:     "https://api.monzo.com/webhooks?account_id=%s",
 	WebhookCreateURL:  "https://api.monzo.com/webhooks",
 	WebhookDeleteURL:  "https://api.monzo.com/webhooks/%s",
 	FeedItemCreateURL: "https://api.monzo.com/feed",
 }
 
+var errNoAccounts = errors.New("no accounts found for your account")
+
 type Monzo struct {
 	tokenType   string
 	accessToken string
+	client      *http.Client
+	ctx         context.Context
+	response    *ResponseRecorder
+	middleware  []Middleware
 }
 
 func GetURL(urlname string, params ...interface{}) string {
@@ -78,39 +94,76 @@ func SetURL(urlname string, newURL string) {
 	urlMap[urlname] = newURL
 }
 
-func New(tokenType string, accessToken string) Monzo {
-	return Monzo{
+func New(tokenType string, accessToken string, options ...Option) Monzo {
+	m := Monzo{
 		tokenType:   tokenType,
 		accessToken: accessToken,
 	}
+
+	for _, option := range DefaultOptions {
+		option(&m)
+	}
+
+	for _, option := range options {
+		option(&m)
+	}
+
+	return m
 }
 
 func Login(state string) string {
-	return GetURL(LoginURL, ClientID, RedirectURI, state)
+	return loginURL(state, RedirectURI, "")
+}
+
+func LoginPKCE(state string, codeChallenge string) string {
+	return loginURL(state, RedirectURI, codeChallenge)
+}
+
+func loginURL(state string, redirectURI string, codeChallenge string) string {
+	targetURL := GetURL(LoginURL, ClientID, url.QueryEscape(redirectURI), url.QueryEscape(state))
+	if codeChallenge == "" {
+		return targetURL
+	}
+
+	return targetURL + "&code_challenge=" + url.QueryEscape(codeChallenge) + "&code_challenge_method=" + codeChallengeMethod
 }
 
 func Callback(code string) (model.User, error) {
-	headers := make(httpc.Headers)
-	headers.FormURLEncoded()
+	return CallbackContext(context.Background(), code)
+}
 
+func CallbackContext(ctx context.Context, code string) (model.User, error) {
+	return exchange(ctx, code, RedirectURI, "")
+}
+
+func CallbackPKCE(code string, codeVerifier string) (model.User, error) {
+	return CallbackPKCEContext(context.Background(), code, codeVerifier)
+}
+
+func CallbackPKCEContext(ctx context.Context, code string, codeVerifier string) (model.User, error) {
+	return exchange(ctx, code, RedirectURI, codeVerifier)
+}
+
+func exchange(ctx context.Context, code string, redirectURI string, codeVerifier string) (model.User, error) {
 	data := map[string]string{
-		"grant_type":    "authorization_code",
-		"client_id":     ClientID,
-		"client_secret": ClientSecret,
-		"redirect_uri":  RedirectURI,
-		"code":          code,
+		"grant_type":   "authorization_code",
+		"client_id":    ClientID,
+		"redirect_uri": redirectURI,
+		"code":         code,
 	}
 
-	targetURL := GetURL(Oauth2URL)
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodPost,
-		Headers:   headers,
-		Form:      data,
+	if ClientSecret != "" {
+		data["client_secret"] = ClientSecret
 	}
 
+	if codeVerifier != "" {
+		data["code_verifier"] = codeVerifier
+	}
+
+	targetURL := GetURL(Oauth2URL)
+
 	var user model.User
-	if err := request.JSON(&user); err != nil {
+	if err := New("", "").WithContext(ctx).do(OpTokenExchange, http.MethodPost, targetURL, data, &user); err != nil {
 		return model.User{}, err
 	}
 
@@ -120,8 +173,19 @@ func Callback(code string) (model.User, error) {
 }
 
 func Refresh(refreshToken string) (model.User, error) {
-	headers := make(httpc.Headers)
-	headers.FormURLEncoded()
+	return RefreshContext(context.Background(), refreshToken)
+}
+
+func RefreshContext(ctx context.Context, refreshToken string) (model.User, error) {
+	return refresh(ctx, refreshToken)
+}
+
+// refresh sends the refresh through a client with options, so a TokenSource
+// refreshes with the same HTTP client and middleware as its clients.
+func refresh(ctx context.Context, refreshToken string, options ...Option) (model.User, error) {
+	if !Confidential {
+		return model.User{}, ErrRefreshUnsupported
+	}
 
 	data := make(map[string]string)
 	data["grant_type"] = "refresh_token"
@@ -129,15 +193,8 @@ func Refresh(refreshToken string) (model.User, error) {
 	data["client_secret"] = ClientSecret
 	data["refresh_token"] = refreshToken
 
-	request := httpc.HTTP{
-		TargetURL: GetURL(Oauth2URL),
-		Method:    http.MethodPost,
-		Headers:   headers,
-		Form:      data,
-	}
-
 	var user model.User
-	if err := request.JSON(&user); err != nil {
+	if err := New("", "", options...).WithContext(ctx).do(OpTokenRefresh, http.MethodPost, GetURL(Oauth2URL), data, &user); err != nil {
 		return model.User{}, err
 	} else {
 		user.UpdateExpiry()
@@ -147,40 +204,56 @@ func Refresh(refreshToken string) (model.User, error) {
 }
 
 func (m Monzo) WhoAmI() (model.WhoAmI, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-	headers.FormURLEncoded()
-
 	targetURL := GetURL(WhoAmIURL)
 
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodPut,
-		Headers:   headers,
-		Form:      nil,
-	}
-
 	var whoami model.WhoAmI
-	if err := request.JSON(&whoami); err != nil {
+	if err := m.do(OpWhoAmI, http.MethodGet, targetURL, nil, &whoami); err != nil {
 		return model.WhoAmI{}, err
 	}
 
 	return whoami, nil
 }
 
-func (m Monzo) Accounts() (model.Monzo, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
+func (m Monzo) TokenInfo(user model.User) (model.TokenInfo, error) {
+	whoami, err := m.WhoAmI()
+	if err != nil {
+		return model.TokenInfo{}, err
+	}
+
+	return tokenInfo(whoami, user), nil
+}
+
+func tokenInfo(whoami model.WhoAmI, user model.User) model.TokenInfo {
+	remaining := time.Until(user.ExpiryDate)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	info := model.TokenInfo{
+		WhoAmI:     whoami,
+		ExpiryDate: user.ExpiryDate,
+		Remaining:  remaining,
+		CanRefresh: CanRefresh(user),
+	}
 
-	request := httpc.HTTP{
-		TargetURL: GetURL(AccountsURL),
-		Method:    http.MethodGet,
-		Headers:   headers,
-		Form:      nil,
+	return info
+}
+
+func (m Monzo) Logout() error {
+	if err := m.do(OpLogout, http.MethodPost, GetURL(LogoutURL), nil, nil); err != nil {
+		if isTokenInvalid(err) {
+			return ErrTokenInvalid
+		}
+
+		return err
 	}
 
+	return nil
+}
+
+func (m Monzo) Accounts() (model.Monzo, error) {
 	var monzo model.Monzo
-	if err := request.JSON(&monzo); err != nil {
+	if err := m.do(OpAccountsList, http.MethodGet, GetURL(AccountsURL), nil, &monzo); err != nil {
 		return model.Monzo{}, err
 	}
 
@@ -188,42 +261,23 @@ func (m Monzo) Accounts() (model.Monzo, error) {
 }
 
 func (m Monzo) CurrentAccount() (model.Account, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-
-	request := httpc.HTTP{
-		TargetURL: GetURL(AccountsURL),
-		Method:    http.MethodGet,
-		Headers:   headers,
-		Form:      nil,
-	}
-
 	var monzo model.Monzo
-	if err := request.JSON(&monzo); err != nil {
+	if err := m.do(OpAccountsList, http.MethodGet, GetURL(AccountsURL), nil, &monzo); err != nil {
 		return model.Account{}, err
 	}
 
 	if len(monzo.Accounts) == 0 {
-		return model.Account{}, errors.New("no accounts found for your account")
+		return model.Account{}, errNoAccounts
 	}
 
 	return monzo.Accounts[0], nil
 }
 
 func (m Monzo) Balance(accountID string) (model.Balance, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-
 	targetURL := GetURL(BalanceURL, accountID)
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodGet,
-		Headers:   headers,
-		Form:      nil,
-	}
 
 	var balance model.Balance
-	if err := request.JSON(&balance); err != nil {
+	if err := m.do(OpBalanceRead, http.MethodGet, targetURL, nil, &balance); err != nil {
 		return model.Balance{}, err
 	}
 
@@ -231,43 +285,51 @@ func (m Monzo) Balance(accountID string) (model.Balance, error) {
 }
 
 func (m Monzo) Pots() (model.Monzo, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
+	var monzo model.Monzo
+	if err := m.do(OpPotsList, http.MethodGet, GetURL(PotsURL), nil, &monzo); err != nil {
+		return model.Monzo{}, err
+	}
+
+	return monzo, nil
+}
+
+func (m Monzo) AccountPots(accountID string) ([]model.Pot, error) {
+	var monzo model.Monzo
+	if err := m.do(OpPotsList, http.MethodGet, GetURL(AccountPotsURL, accountID), nil, &monzo); err != nil {
+		return nil, err
+	}
 
-	request := httpc.HTTP{
-		TargetURL: GetURL(PotsURL),
-		Method:    http.MethodGet,
-		Headers:   headers,
-		Form:      nil,
+	return monzo.Pots, nil
+}
+
+func (m Monzo) Transactions(accountID string, params TransactionParams) ([]model.TransactionData, error) {
+	targetURL := GetURL(TransactionsURL, url.QueryEscape(accountID))
+	if query := params.encode(); query != "" {
+		targetURL += "&" + query
 	}
 
 	var monzo model.Monzo
-	if err := request.JSON(&monzo); err != nil {
-		return model.Monzo{}, err
+	if err := m.do(OpTransactionsList, http.MethodGet, targetURL, nil, &monzo); err != nil {
+		return nil, err
 	}
 
-	return monzo, nil
+	return monzo.Transactions, nil
 }
 
 func (m Monzo) RegisterWebhook(accountID string) (model.Webhook, error) {
-	headers := httpc.Headers{}
-	headers.FormURLEncoded()
-	headers.Authorization(m.tokenType, m.accessToken)
+	return m.RegisterWebhookURL(accountID, WebhookURI)
+}
 
+// RegisterWebhookURL registers webhookURL rather than the package level
+// WebhookURI.
+func (m Monzo) RegisterWebhookURL(accountID string, webhookURL string) (model.Webhook, error) {
 	data := map[string]string{
 		"account_id": accountID,
-		"url":        WebhookURI,
-	}
-
-	request := httpc.HTTP{
-		TargetURL: GetURL(WebhookCreateURL),
-		Method:    http.MethodPost,
-		Headers:   headers,
-		Form:      data,
+		"url":        webhookURL,
 	}
 
 	var monzo model.Monzo
-	if err := request.JSON(&monzo); err != nil {
+	if err := m.do(OpWebhooksRegister, http.MethodPost, GetURL(WebhookCreateURL), data, &monzo); err != nil {
 		return model.Webhook{}, err
 	}
 
@@ -275,17 +337,7 @@ func (m Monzo) RegisterWebhook(accountID string) (model.Webhook, error) {
 }
 
 func (m Monzo) DeleteWebhook(webhookID string) error {
-	headers := httpc.Headers{}
-	headers.Authorization(m.tokenType, m.accessToken)
-
-	request := httpc.HTTP{
-		TargetURL: GetURL(WebhookDeleteURL, webhookID),
-		Method:    http.MethodDelete,
-		Headers:   headers,
-		Form:      nil,
-	}
-
-	if _, err := request.String(); err != nil {
+	if err := m.do(OpWebhooksDelete, http.MethodDelete, GetURL(WebhookDeleteURL, webhookID), nil, nil); err != nil {
 		return err
 	}
 
@@ -295,18 +347,8 @@ func (m Monzo) DeleteWebhook(webhookID string) error {
 func (m Monzo) Webhooks(accountID string) ([]model.Webhook, error) {
 	targetURL := GetURL(WebhookGetURL, accountID)
 
-	headers := httpc.Headers{}
-	headers.Authorization(m.tokenType, m.accessToken)
-
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Headers:   headers,
-		Form:      nil,
-	}
-
 	var monzo model.Monzo
-	if err := request.JSON(&monzo); err != nil {
-		logio.Println(err)
+	if err := m.do(OpWebhooksList, http.MethodGet, targetURL, nil, &monzo); err != nil {
 		return nil, err
 	}
 
@@ -314,26 +356,21 @@ func (m Monzo) Webhooks(accountID string) ([]model.Webhook, error) {
 }
 
 func (m Monzo) Withdraw(sourcePotID string, destinationAccountID string, amount int64) (model.Pot, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-	headers.FormURLEncoded()
+	return m.WithdrawWithDedupe(sourcePotID, destinationAccountID, amount, NewDedupeID())
+}
 
+// WithdrawWithDedupe withdraws with the caller's dedupe ID, so retrying with
+// the same ID after a lost response moves the money at most once.
+func (m Monzo) WithdrawWithDedupe(sourcePotID string, destinationAccountID string, amount int64, dedupeID string) (model.Pot, error) {
 	data := make(map[string]string)
 	data["destination_account_id"] = destinationAccountID
 	data["amount"] = strconv.FormatInt(amount, 10)
-	data["dedupe_id"] = uuid.Token()
+	data["dedupe_id"] = dedupeID
 
 	targetURL := GetURL(WithdrawURL, sourcePotID)
 
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodPut,
-		Headers:   headers,
-		Form:      data,
-	}
-
 	var pot model.Pot
-	if err := request.JSON(&pot); err != nil {
+	if err := m.do(OpPotsWithdraw, http.MethodPut, targetURL, data, &pot); err != nil {
 		return model.Pot{}, err
 	}
 
@@ -341,57 +378,74 @@ func (m Monzo) Withdraw(sourcePotID string, destinationAccountID string, amount
 }
 
 func (m Monzo) Deposit(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error) {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-	headers.FormURLEncoded()
+	return m.DepositWithDedupe(targetPotID, sourceAccountID, amount, NewDedupeID())
+}
 
+// DepositWithDedupe deposits with the caller's dedupe ID, so retrying with
+// the same ID after a lost response moves the money at most once.
+func (m Monzo) DepositWithDedupe(targetPotID string, sourceAccountID string, amount int64, dedupeID string) (model.Pot, error) {
 	data := make(map[string]string)
 	data["source_account_id"] = sourceAccountID
 	data["amount"] = strconv.FormatInt(amount, 10)
-	data["dedupe_id"] = uuid.Token()
+	data["dedupe_id"] = dedupeID
 
 	targetURL := GetURL(DepositURL, targetPotID)
 
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodPut,
-		Headers:   headers,
-		Form:      data,
-	}
-
 	var pot model.Pot
-	if err := request.JSON(&pot); err != nil {
+	if err := m.do(OpPotsDeposit, http.MethodPut, targetURL, data, &pot); err != nil {
 		return model.Pot{}, err
 	}
 
 	return pot, nil
 }
 
-func (m Monzo) CreateFeedItem(accountID string, title string, body string, imageURL string) error {
-	headers := make(httpc.Headers)
-	headers.Authorization(m.tokenType, m.accessToken)
-	headers.FormURLEncoded()
+// NewDedupeID returns a random ID for DepositWithDedupe or WithdrawWithDedupe.
+// Keep it for retries of the same transfer.
+func NewDedupeID() string {
+	return uuid.Token()
+}
 
-	data := make(map[string]string)
-	data["account_id"] = accountID
-	data["type"] = "basic"
-	// data["url"] = "https://monzo.com"
-	data["params[title]"] = title
-	data["params[body]"] = body
-	data["params[image_url]"] = imageURL
+// WithdrawMoney moves amount from sourcePot, refusing an amount in a
+// currency other than the pot's.
+func (m Monzo) WithdrawMoney(sourcePot model.Pot, destinationAccountID string, amount model.Money) (model.Pot, error) {
+	if err := validateTransfer(sourcePot, amount); err != nil {
+		return model.Pot{}, err
+	}
+
+	return m.Withdraw(sourcePot.ID, destinationAccountID, amount.Amount)
+}
 
-	targetURL := GetURL(FeedItemCreateURL)
+// DepositMoney moves amount into targetPot, refusing an amount in a currency
+// other than the pot's.
+func (m Monzo) DepositMoney(targetPot model.Pot, sourceAccountID string, amount model.Money) (model.Pot, error) {
+	if err := validateTransfer(targetPot, amount); err != nil {
+		return model.Pot{}, err
+	}
 
-	request := httpc.HTTP{
-		TargetURL: targetURL,
-		Method:    http.MethodPost,
-		Headers:   headers,
-		Form:      data,
+	return m.Deposit(targetPot.ID, sourceAccountID, amount.Amount)
+}
+
+func validateTransfer(pot model.Pot, amount model.Money) error {
+	if amount.Currency == "" {
+		return errors.New("transfer amount has no currency")
 	}
 
-	if _, err := request.Status(); err != nil {
-		return err
+	if pot.Currency == "" {
+		return errors.Errorf("pot %s has no currency", pot.ID)
+	}
+
+	if amount.Currency != pot.Currency {
+		return errors.Errorf("transfer amount is in %s but pot %s holds %s", amount.Currency, pot.ID, pot.Currency)
+	}
+
+	if amount.Amount <= 0 {
+		return errors.Errorf("transfer amount must be positive, got %s", amount)
 	}
 
 	return nil
 }
+
+func (m Monzo) CreateFeedItem(accountID string, title string, body string, imageURL string) error {
+	item := NewFeedItem(title, imageURL).WithBody(body)
+	return m.PostFeedItem(accountID, item)
+}
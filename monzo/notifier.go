@@ -0,0 +1,294 @@
+package monzo
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSummaryTitle = `{{.Count}} new notifications`
+	defaultSummaryBody  = `{{range $i, $item := .Items}}{{if $i}}, {{end}}{{$item.Title}}{{end}}`
+)
+
+var ErrRateLimited = errors.New("feed item dropped, account notification limit reached")
+
+type NotifierConfig struct {
+	TitleTemplate        string
+	BodyTemplate         string
+	SummaryTitleTemplate string
+	SummaryBodyTemplate  string
+	ImageURL             string
+	URL                  string
+	Window               time.Duration
+	MaxPerHour           int
+	OnError              func(accountID string, err error)
+}
+
+type Notification struct {
+	Title string
+	Body  string
+}
+
+type NotificationSummary struct {
+	AccountID string
+	Count     int
+	Items     []Notification
+}
+
+type Notifier struct {
+	monzo  API
+	config NotifierConfig
+
+	title        *template.Template
+	body         *template.Template
+	summaryTitle *template.Template
+	summaryBody  *template.Template
+
+	mu      sync.Mutex
+	pending map[string][]Notification
+	timers  map[string]*time.Timer
+	sent    map[string][]time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"amount": func(minorUnits int64, currency string) string {
+		return model.NewMoney(minorUnits, currency).Major()
+	},
+	"money": func(minorUnits int64, currency string) string {
+		return model.NewMoney(minorUnits, currency).String()
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func NewNotifier(m API, config NotifierConfig) (*Notifier, error) {
+	if config.TitleTemplate == "" {
+		return nil, errors.New("notifier title template is required")
+	}
+
+	// Feed items are checked here rather than on every send, where a bad
+	// URL would only reach OnError once notifications are batched.
+	if config.ImageURL == "" {
+		return nil, errors.New("notifier image url is required")
+	}
+
+	if err := validateFeedURL("image url", config.ImageURL); err != nil {
+		return nil, err
+	}
+
+	if config.URL != "" {
+		if err := validateFeedURL("url", config.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.SummaryTitleTemplate == "" {
+		config.SummaryTitleTemplate = defaultSummaryTitle
+	}
+
+	if config.SummaryBodyTemplate == "" {
+		config.SummaryBodyTemplate = defaultSummaryBody
+	}
+
+	n := &Notifier{
+		monzo:   m,
+		config:  config,
+		pending: make(map[string][]Notification),
+		timers:  make(map[string]*time.Timer),
+		sent:    make(map[string][]time.Time),
+	}
+
+	templates := []struct {
+		name   string
+		text   string
+		target **template.Template
+	}{
+		{"title", config.TitleTemplate, &n.title},
+		{"body", config.BodyTemplate, &n.body},
+		{"summary title", config.SummaryTitleTemplate, &n.summaryTitle},
+		{"summary body", config.SummaryBodyTemplate, &n.summaryBody},
+	}
+
+	for _, t := range templates {
+		parsed, err := template.New(t.name).Funcs(templateFuncs).Parse(t.text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s template", t.name)
+		}
+
+		*t.target = parsed
+	}
+
+	return n, nil
+}
+
+// Notify renders a feed item from data and sends it, coalescing it with any
+// other notifications for the same account that arrive within the window.
+func (n *Notifier) Notify(accountID string, data interface{}) error {
+	title, err := render(n.title, data)
+	if err != nil {
+		return err
+	}
+
+	body, err := render(n.body, data)
+	if err != nil {
+		return err
+	}
+
+	notification := Notification{Title: title, Body: body}
+
+	if n.config.Window <= 0 {
+		return n.send(accountID, []Notification{notification})
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.pending[accountID] = append(n.pending[accountID], notification)
+	if _, ok := n.timers[accountID]; !ok {
+		n.timers[accountID] = time.AfterFunc(n.config.Window, func() {
+			if err := n.flush(accountID); err != nil && n.config.OnError != nil {
+				n.config.OnError(accountID, err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Flush immediately sends every pending burst, e.g. before shutting down.
+func (n *Notifier) Flush() error {
+	n.mu.Lock()
+	accountIDs := make([]string, 0, len(n.pending))
+	for accountID := range n.pending {
+		accountIDs = append(accountIDs, accountID)
+	}
+	n.mu.Unlock()
+
+	var firstErr error
+	for _, accountID := range accountIDs {
+		if err := n.flush(accountID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (n *Notifier) flush(accountID string) error {
+	n.mu.Lock()
+	notifications := n.pending[accountID]
+	if timer, ok := n.timers[accountID]; ok {
+		timer.Stop()
+	}
+
+	delete(n.pending, accountID)
+	delete(n.timers, accountID)
+	n.mu.Unlock()
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return n.send(accountID, notifications)
+}
+
+func (n *Notifier) send(accountID string, notifications []Notification) error {
+	sentAt, ok := n.allow(accountID)
+	if !ok {
+		return ErrRateLimited
+	}
+
+	notification := notifications[0]
+	if len(notifications) > 1 {
+		summary := NotificationSummary{
+			AccountID: accountID,
+			Count:     len(notifications),
+			Items:     notifications,
+		}
+
+		title, err := render(n.summaryTitle, summary)
+		if err != nil {
+			return err
+		}
+
+		body, err := render(n.summaryBody, summary)
+		if err != nil {
+			return err
+		}
+
+		notification = Notification{Title: title, Body: body}
+	}
+
+	item := NewFeedItem(notification.Title, n.config.ImageURL).WithBody(notification.Body)
+	if n.config.URL != "" {
+		item.WithURL(n.config.URL)
+	}
+
+	if err := n.monzo.PostFeedItem(accountID, item); err != nil {
+		n.release(accountID, sentAt)
+		return err
+	}
+
+	return nil
+}
+
+// allow reserves one of the account's hourly slots, returning when it was
+// taken so a failed send can hand it back.
+func (n *Notifier) allow(accountID string) (time.Time, bool) {
+	now := time.Now()
+	if n.config.MaxPerHour <= 0 {
+		return now, true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	cutoff := now.Add(-time.Hour)
+
+	recent := n.sent[accountID][:0]
+	for _, sentAt := range n.sent[accountID] {
+		if sentAt.After(cutoff) {
+			recent = append(recent, sentAt)
+		}
+	}
+
+	if len(recent) >= n.config.MaxPerHour {
+		n.sent[accountID] = recent
+		return time.Time{}, false
+	}
+
+	n.sent[accountID] = append(recent, now)
+	return now, true
+}
+
+func (n *Notifier) release(accountID string, sentAt time.Time) {
+	if n.config.MaxPerHour <= 0 {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sent := n.sent[accountID]
+	for i, at := range sent {
+		if at.Equal(sentAt) {
+			n.sent[accountID] = append(sent[:i], sent[i+1:]...)
+			return
+		}
+	}
+}
+
+func render(tmpl *template.Template, data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", errors.Wrapf(err, "unable to render %s template", tmpl.Name())
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,104 @@
+package monzo
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SensitiveFields are replaced with REDACTED wherever a request URL is
+// logged.
+var SensitiveFields = []string{
+	"access_token",
+	"refresh_token",
+	"client_secret",
+	"code",
+	"code_verifier",
+	"dedupe_id",
+	"account_number",
+	"sort_code",
+}
+
+const redacted = "REDACTED"
+
+// WithLogger logs every call made by the client at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return WithMiddleware(StructuredLogging(logger))
+}
+
+// StructuredLogging logs the operation, method, redacted URL, status,
+// duration and Monzo request ID of each call. Headers, form values and
+// bodies are never logged.
+func StructuredLogging(logger *slog.Logger) Middleware {
+	return func(next Doer) Doer {
+		return func(call *Call) (*http.Response, error) {
+			ctx := call.Request.Context()
+			if !logger.Enabled(ctx, slog.LevelDebug) {
+				return next(call)
+			}
+
+			start := time.Now()
+			response, err := next(call)
+
+			attrs := []slog.Attr{
+				slog.String("operation", call.Operation),
+				slog.String("method", call.Request.Method),
+				slog.String("url", redactURL(call.Request.URL)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if response != nil {
+				attrs = append(attrs,
+					slog.Int("status", response.StatusCode),
+					slog.String("request_id", response.Header.Get(requestIDHeader)),
+				)
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.String("error", redactError(err).Error()))
+			}
+
+			logger.LogAttrs(ctx, slog.LevelDebug, "monzo request", attrs...)
+
+			return response, err
+		}
+	}
+}
+
+func (m Monzo) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token_type", m.tokenType),
+		slog.String("access_token", redacted),
+	)
+}
+
+func redactURL(u *url.URL) string {
+	copied := *u
+	copied.User = nil
+
+	if copied.RawQuery != "" {
+		query := copied.Query()
+		for _, field := range SensitiveFields {
+			if _, ok := query[field]; ok {
+				query.Set(field, redacted)
+			}
+		}
+
+		copied.RawQuery = query.Encode()
+	}
+
+	return copied.String()
+}
+
+// redactError strips query strings from transport errors, which quote the
+// full request URL.
+func redactError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		if parsed, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			return &url.Error{Op: urlErr.Op, URL: redactURL(parsed), Err: urlErr.Err}
+		}
+	}
+
+	return err
+}
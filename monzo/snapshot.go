@@ -0,0 +1,104 @@
+package monzo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+)
+
+var SnapshotConcurrency = 4
+
+// snapshotReader is the part of API a snapshot reads through, so Cached can
+// take snapshots from its cache.
+type snapshotReader interface {
+	Accounts() (model.Monzo, error)
+	Balance(accountID string) (model.Balance, error)
+	AccountPots(accountID string) ([]model.Pot, error)
+}
+
+// Snapshot loads every account with its balance and pots concurrently. A
+// failure loading one account is recorded on that account's snapshot rather
+// than failing the whole snapshot.
+func (m Monzo) Snapshot(ctx context.Context) (model.Snapshot, error) {
+	return takeSnapshot(ctx, m.WithContext(ctx))
+}
+
+func takeSnapshot(ctx context.Context, m snapshotReader) (model.Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Snapshot{}, err
+	}
+
+	monzo, err := m.Accounts()
+	if err != nil {
+		return model.Snapshot{}, err
+	}
+
+	snapshot := model.Snapshot{
+		Taken:    time.Now(),
+		Accounts: make([]model.AccountSnapshot, len(monzo.Accounts)),
+	}
+
+	concurrency := SnapshotConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	run := func(account *model.AccountSnapshot, load func() (func(), error)) {
+		defer wg.Done()
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			recordError(account, ctx.Err())
+			mu.Unlock()
+			return
+		}
+
+		apply, err := load()
+		<-slots
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			recordError(account, err)
+			return
+		}
+
+		apply()
+	}
+
+	for i := range snapshot.Accounts {
+		account := &snapshot.Accounts[i]
+		account.Account = monzo.Accounts[i]
+		accountID := account.Account.ID
+
+		wg.Add(2)
+		go run(account, func() (func(), error) {
+			balance, err := m.Balance(accountID)
+			return func() { account.Balance = balance }, err
+		})
+		go run(account, func() (func(), error) {
+			pots, err := m.AccountPots(accountID)
+			return func() { account.Pots = pots }, err
+		})
+	}
+
+	wg.Wait()
+
+	return snapshot, nil
+}
+
+func recordError(account *model.AccountSnapshot, err error) {
+	if account.Err == nil {
+		account.Err = err
+	}
+}
@@ -0,0 +1,241 @@
+package monzo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	badAccessToken       = "unauthorized.bad_access_token"
+	defaultRefreshWindow = time.Minute
+)
+
+var (
+	ErrTokenInvalid       = errors.New("access token is invalid, expired or already revoked")
+	ErrNoToken            = errors.New("no token stored")
+	ErrRefreshUnsupported = errors.New("refresh tokens are only issued to confidential clients, log in again")
+	ErrLoginRequired      = errors.New("access token has expired and cannot be refreshed, log in again")
+)
+
+type TokenStore interface {
+	Load() (model.User, error)
+	Save(user model.User) error
+	Delete() error
+}
+
+type MemoryTokenStore struct {
+	mu   sync.Mutex
+	user *model.User
+}
+
+func NewMemoryTokenStore(user model.User) *MemoryTokenStore {
+	return &MemoryTokenStore{user: &user}
+}
+
+func (s *MemoryTokenStore) Load() (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.user == nil {
+		return model.User{}, ErrNoToken
+	}
+
+	return *s.user, nil
+}
+
+func (s *MemoryTokenStore) Save(user model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user = &user
+	return nil
+}
+
+func (s *MemoryTokenStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user = nil
+	return nil
+}
+
+// FileTokenStore keeps the token as JSON in a file readable only by its
+// owner. Saves go through a temporary file so a crash never leaves a
+// truncated token behind.
+type FileTokenStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+func (s *FileTokenStore) Load() (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return model.User{}, ErrNoToken
+	} else if err != nil {
+		return model.User{}, err
+	}
+
+	var user model.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return model.User{}, errors.Wrapf(err, "unable to read token file %s", s.Path)
+	}
+
+	return user, nil
+}
+
+func (s *FileTokenStore) Save(user model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(s.Path)+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.Path)
+}
+
+func (s *FileTokenStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// LogoutAndForget revokes the token and removes it from the store. The token
+// is purged even when Monzo reports it as already invalid, in which case
+// ErrTokenInvalid is still returned.
+func (m Monzo) LogoutAndForget(store TokenStore) error {
+	err := m.Logout()
+	if err != nil && err != ErrTokenInvalid {
+		return err
+	}
+
+	if deleteErr := store.Delete(); deleteErr != nil {
+		return errors.Wrap(deleteErr, "unable to purge token from store")
+	}
+
+	return err
+}
+
+func CanRefresh(user model.User) bool {
+	return Confidential && user.RefreshToken != ""
+}
+
+// TokenSource hands out a valid token from its store, refreshing it shortly
+// before expiry when the client is allowed to.
+type TokenSource struct {
+	Store         TokenStore
+	RefreshWindow time.Duration
+	Options       []Option
+
+	mu sync.Mutex
+}
+
+func NewTokenSource(store TokenStore) *TokenSource {
+	return &TokenSource{
+		Store:         store,
+		RefreshWindow: defaultRefreshWindow,
+	}
+}
+
+func (ts *TokenSource) CanRefresh() bool {
+	user, err := ts.Store.Load()
+	if err != nil {
+		return false
+	}
+
+	return CanRefresh(user)
+}
+
+func (ts *TokenSource) Token() (model.User, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	user, err := ts.Store.Load()
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if time.Until(user.ExpiryDate) > ts.RefreshWindow {
+		return user, nil
+	}
+
+	if !CanRefresh(user) {
+		if time.Now().Before(user.ExpiryDate) {
+			return user, nil
+		}
+
+		return model.User{}, ErrLoginRequired
+	}
+
+	refreshed, err := refresh(context.Background(), user.RefreshToken, ts.Options...)
+	if err != nil {
+		return model.User{}, errors.Wrap(err, "unable to refresh access token")
+	}
+
+	if err := ts.Store.Save(refreshed); err != nil {
+		return model.User{}, errors.Wrap(err, "unable to save refreshed token")
+	}
+
+	return refreshed, nil
+}
+
+func (ts *TokenSource) Client() (Monzo, error) {
+	user, err := ts.Token()
+	if err != nil {
+		return Monzo{}, err
+	}
+
+	return New(user.TokenType, user.AccessToken, ts.Options...), nil
+}
+
+func isTokenInvalid(err error) bool {
+	return HasCode(err, badAccessToken)
+}
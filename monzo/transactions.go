@@ -0,0 +1,38 @@
+package monzo
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const MaxTransactionsLimit = 100
+
+// TransactionParams pages through transactions. Since may be a time or, via
+// SinceID, the ID of the last transaction already seen.
+type TransactionParams struct {
+	Since   time.Time
+	SinceID string
+	Before  time.Time
+	Limit   int
+}
+
+func (p TransactionParams) encode() string {
+	values := url.Values{}
+
+	if p.SinceID != "" {
+		values.Set("since", p.SinceID)
+	} else if !p.Since.IsZero() {
+		values.Set("since", p.Since.UTC().Format(time.RFC3339Nano))
+	}
+
+	if !p.Before.IsZero() {
+		values.Set("before", p.Before.UTC().Format(time.RFC3339Nano))
+	}
+
+	if p.Limit > 0 {
+		values.Set("limit", strconv.Itoa(p.Limit))
+	}
+
+	return values.Encode()
+}
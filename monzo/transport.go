@@ -0,0 +1,208 @@
+package monzo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+var (
+	// DefaultHTTPClient is used by clients built without WithHTTPClient and
+	// by the package level OAuth functions.
+	DefaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+	// MaxResponseSize caps how much of a response body is read.
+	MaxResponseSize int64 = 10 << 20
+
+	// DefaultOptions apply to every client before its own options, and to
+	// the package level OAuth functions.
+	DefaultOptions []Option
+)
+
+var ErrResponseTooLarge = errors.New("monzo response body exceeds MaxResponseSize")
+
+type Option func(*Monzo)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *Monzo) {
+		m.client = client
+	}
+}
+
+// Response is the metadata of a response. Errors carry their own; for
+// successful calls it is kept by a ResponseRecorder.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	RequestID  string
+}
+
+// ResponseRecorder keeps the metadata of the last response received by a
+// client from WithResponse. It is safe to share between goroutines, but with
+// concurrent calls, such as a Snapshot, Last is simply whichever response
+// arrived last; use a recorder per goroutine to tie metadata to a call.
+type ResponseRecorder struct {
+	mu   sync.Mutex
+	last Response
+}
+
+func (r *ResponseRecorder) Last() Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.last
+}
+
+func (r *ResponseRecorder) record(response Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = response
+}
+
+// Error is returned for any non 2xx response. Code and Message are parsed
+// from Monzo's error body; Error() returns the body unchanged.
+type Error struct {
+	Response
+
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Body    string `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("monzo: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return e.Body
+}
+
+// HasCode reports whether err is an *Error with the given code or one of its
+// sub-codes, so "unauthorized.bad_access_token" matches
+// "unauthorized.bad_access_token.expired".
+func HasCode(err error, code string) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Code == code || strings.HasPrefix(apiErr.Code, code+".")
+}
+
+// WithResponse returns a copy of the client that records the metadata of
+// each response it receives, successful or not.
+func (m Monzo) WithResponse(recorder *ResponseRecorder) Monzo {
+	m.response = recorder
+	return m
+}
+
+// WithContext returns a copy of the client whose requests are sent with ctx,
+// so they are cancelled with it and carry its values, such as trace spans.
+//
+//	balance, err := client.WithContext(ctx).Balance(accountID)
+func (m Monzo) WithContext(ctx context.Context) Monzo {
+	m.ctx = ctx
+	return m
+}
+
+func (m Monzo) context() context.Context {
+	if m.ctx != nil {
+		return m.ctx
+	}
+
+	return context.Background()
+}
+
+func (m Monzo) httpClient() *http.Client {
+	if m.client != nil {
+		return m.client
+	}
+
+	return DefaultHTTPClient
+}
+
+func (m Monzo) send(call *Call) (*http.Response, error) {
+	return m.httpClient().Do(call.Request)
+}
+
+// do sends a request through the middleware chain, form encoding data when it
+// is not nil, and decodes a successful JSON body into result when result is
+// not nil.
+func (m Monzo) do(operation string, method string, targetURL string, data map[string]string, result interface{}) error {
+	var body io.Reader
+	if data != nil {
+		values := url.Values{}
+		for key, value := range data {
+			values.Set(key, value)
+		}
+
+		body = strings.NewReader(values.Encode())
+	}
+
+	request, err := http.NewRequestWithContext(m.context(), method, targetURL, body)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	if data != nil {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if m.accessToken != "" {
+		request.Header.Set("Authorization", m.tokenType+" "+m.accessToken)
+	}
+
+	send := chain(m.middleware, m.send)
+
+	response, err := send(&Call{Operation: operation, Request: request})
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	payload, err := ioutil.ReadAll(io.LimitReader(response.Body, MaxResponseSize+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(payload)) > MaxResponseSize {
+		return ErrResponseTooLarge
+	}
+
+	metadata := Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		RequestID:  response.Header.Get(requestIDHeader),
+	}
+
+	if m.response != nil {
+		m.response.record(metadata)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiErr := &Error{Response: metadata, Body: string(payload)}
+		json.Unmarshal(payload, apiErr)
+
+		return apiErr
+	}
+
+	if result == nil || len(payload) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(payload, result)
+}
@@ -0,0 +1,448 @@
+package monzotest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// RedactedHeaders and RedactedFields are scrubbed from every recorded
+// request and response. Fields are matched in form values, query strings
+// and JSON bodies at any depth.
+var (
+	RedactedHeaders = []string{"Authorization"}
+	RedactedFields  = []string{"access_token", "refresh_token", "client_secret", "account_number", "sort_code"}
+)
+
+// ReplayIgnoredFields are left out when matching requests during replay
+// because the client generates them afresh on every call.
+var ReplayIgnoredFields = []string{"dedupe_id"}
+
+type CassetteRequest struct {
+	Method  string              `json:"method" yaml:"method"`
+	URL     string              `json:"url" yaml:"url"`
+	Headers map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Form    map[string][]string `json:"form,omitempty" yaml:"form,omitempty"`
+	Body    string              `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int                 `json:"status_code" yaml:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string              `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+type Interaction struct {
+	Request  CassetteRequest  `json:"request" yaml:"request"`
+	Response CassetteResponse `json:"response" yaml:"response"`
+}
+
+// Cassette is a list of recorded interactions. Files ending in .yaml or .yml
+// are stored as YAML and everything else as JSON.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, &cassette)
+	} else {
+		err = json.Unmarshal(data, &cassette)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("monzotest: cassette %s: %v", path, err)
+	}
+
+	return &cassette, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	var data []byte
+	var err error
+
+	if isYAML(path) {
+		data, err = yaml.Marshal(c)
+	} else {
+		data, err = json.MarshalIndent(c, "", "  ")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Recorder is an http.RoundTripper that passes requests to Transport and
+// keeps a redacted copy of every exchange until Save writes the cassette.
+type Recorder struct {
+	Transport http.RoundTripper
+
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{Transport: transport, path: path}
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readResponseBody(response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redaction can change the body length, so the recorded length is dropped
+	// and recomputed on replay.
+	headers := redactHeaders(response.Header)
+	delete(headers, "Content-Length")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: CassetteResponse{
+			StatusCode: response.StatusCode,
+			Headers:    headers,
+			Body:       redactBody(body),
+		},
+	})
+	r.mu.Unlock()
+
+	return response, nil
+}
+
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cassette.Save(r.path)
+}
+
+// Replayer is an http.RoundTripper that answers from a cassette without
+// touching the network. Requests match on method, path and form values, each
+// interaction is used at most once and anything unmatched is an error.
+type Replayer struct {
+	mu        sync.Mutex
+	cassette  *Cassette
+	used      []bool
+	unmatched []string
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{cassette: cassette, used: make([]bool, len(cassette.Interactions))}, nil
+}
+
+func (r *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	key := matchKey(recorded)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || matchKey(interaction.Request) != key {
+			continue
+		}
+
+		r.used[i] = true
+
+		response := &http.Response{
+			StatusCode:    interaction.Response.StatusCode,
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header(copyValues(interaction.Response.Headers)),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}
+
+		if response.Header == nil {
+			response.Header = make(http.Header)
+		}
+
+		response.Header.Set("Content-Length", strconv.Itoa(len(interaction.Response.Body)))
+
+		return response, nil
+	}
+
+	r.unmatched = append(r.unmatched, key)
+
+	return nil, fmt.Errorf("monzotest: no cassette interaction matches %s", key)
+}
+
+// Unmatched lists the requests that found no interaction.
+func (r *Replayer) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.unmatched...)
+}
+
+// Unused lists the interactions that were never replayed.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+
+	return unused
+}
+
+func recordRequest(request *http.Request) (CassetteRequest, error) {
+	recorded := CassetteRequest{
+		Method:  request.Method,
+		URL:     redactURL(request.URL),
+		Headers: redactHeaders(request.Header),
+	}
+
+	if request.Body == nil {
+		return recorded, nil
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return CassetteRequest{}, err
+	}
+
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if strings.HasPrefix(request.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return CassetteRequest{}, err
+		}
+
+		recorded.Form = redactValues(form)
+	} else if len(body) > 0 {
+		recorded.Body = redactBody(body)
+	}
+
+	return recorded, nil
+}
+
+// readResponseBody buffers the body so it can be both recorded and returned.
+// The standard transport already decompresses gzip it asked for itself, so a
+// gzip body here means the caller set Accept-Encoding and it is decompressed
+// first to keep the cassette readable.
+func readResponseBody(response *http.Response) ([]byte, error) {
+	defer response.Body.Close()
+
+	var body []byte
+	var err error
+
+	if response.Header.Get("Content-Encoding") == "gzip" {
+		reader, gzipErr := gzip.NewReader(response.Body)
+		if gzipErr != nil {
+			return nil, gzipErr
+		}
+
+		body, err = ioutil.ReadAll(reader)
+		response.Header.Del("Content-Encoding")
+	} else {
+		body, err = ioutil.ReadAll(response.Body)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	response.ContentLength = int64(len(body))
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func matchKey(request CassetteRequest) string {
+	path := request.URL
+	form := url.Values{}
+
+	if parsed, err := url.Parse(request.URL); err == nil {
+		path = parsed.Path
+		for key, values := range parsed.Query() {
+			form[key] = values
+		}
+	}
+
+	for key, values := range request.Form {
+		form[key] = append(form[key], values...)
+	}
+
+	for _, field := range ReplayIgnoredFields {
+		form.Del(field)
+	}
+
+	for _, values := range form {
+		sort.Strings(values)
+	}
+
+	key := request.Method + " " + path
+	if encoded := form.Encode(); encoded != "" {
+		key += " " + encoded
+	}
+
+	if request.Body != "" {
+		key += " " + request.Body
+	}
+
+	return key
+}
+
+func redactHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	result := copyValues(headers)
+	for _, name := range RedactedHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := result[name]; ok {
+			result[name] = []string{redacted}
+		}
+	}
+
+	return result
+}
+
+func redactURL(u *url.URL) string {
+	copied := *u
+	if copied.RawQuery != "" {
+		copied.RawQuery = url.Values(redactValues(copied.Query())).Encode()
+	}
+
+	return copied.String()
+}
+
+func redactValues(values url.Values) map[string][]string {
+	result := copyValues(values)
+	for key := range result {
+		if isRedactedField(key) {
+			result[key] = []string{redacted}
+		}
+	}
+
+	return result
+}
+
+func redactBody(body []byte) string {
+	// UseNumber keeps large integers such as amounts and IDs exact rather
+	// than rounding them through float64.
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(redactJSON(decoded))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if isRedactedField(key) {
+				typed[key] = redacted
+			} else {
+				typed[key] = redactJSON(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = redactJSON(child)
+		}
+	}
+
+	return value
+}
+
+func isRedactedField(name string) bool {
+	for _, field := range RedactedFields {
+		if name == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyValues(values map[string][]string) map[string][]string {
+	if values == nil {
+		return nil
+	}
+
+	result := make(map[string][]string, len(values))
+	for key, value := range values {
+		result[key] = append([]string(nil), value...)
+	}
+
+	return result
+}
+
+func isYAML(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	return extension == ".yaml" || extension == ".yml"
+}
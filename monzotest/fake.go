@@ -0,0 +1,309 @@
+// Package monzotest provides test doubles for code built on the monzo client.
+package monzotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fake implements monzo.API. Every call is recorded; results come from the
+// matching Func field when set and are zero values otherwise.
+type Fake struct {
+	WhoAmIFunc          func() (model.WhoAmI, error)
+	TokenInfoFunc       func(user model.User) (model.TokenInfo, error)
+	LogoutFunc          func() error
+	LogoutAndForgetFunc func(store monzo.TokenStore) error
+	WaitForApprovalFunc func(ctx context.Context, pollInterval time.Duration, progress func(monzo.ApprovalProgress)) error
+
+	AccountsFunc       func() (model.Monzo, error)
+	CurrentAccountFunc func() (model.Account, error)
+	BalanceFunc        func(accountID string) (model.Balance, error)
+	PotsFunc           func() (model.Monzo, error)
+	AccountPotsFunc    func(accountID string) ([]model.Pot, error)
+	SnapshotFunc       func(ctx context.Context) (model.Snapshot, error)
+	TransactionsFunc   func(accountID string, params monzo.TransactionParams) ([]model.TransactionData, error)
+
+	WithdrawFunc       func(sourcePotID string, destinationAccountID string, amount int64) (model.Pot, error)
+	DepositFunc        func(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error)
+	WithdrawDedupeFunc func(sourcePotID string, destinationAccountID string, amount int64, dedupeID string) (model.Pot, error)
+	DepositDedupeFunc  func(targetPotID string, sourceAccountID string, amount int64, dedupeID string) (model.Pot, error)
+	WithdrawMoneyFunc  func(sourcePot model.Pot, destinationAccountID string, amount model.Money) (model.Pot, error)
+	DepositMoneyFunc   func(targetPot model.Pot, sourceAccountID string, amount model.Money) (model.Pot, error)
+
+	RegisterWebhookFunc    func(accountID string) (model.Webhook, error)
+	RegisterWebhookURLFunc func(accountID string, webhookURL string) (model.Webhook, error)
+	DeleteWebhookFunc      func(webhookID string) error
+	WebhooksFunc           func(accountID string) ([]model.Webhook, error)
+
+	CreateFeedItemFunc func(accountID string, title string, body string, imageURL string) error
+	PostFeedItemFunc   func(accountID string, item *monzo.FeedItem) error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ monzo.API = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+
+	return calls
+}
+
+func (f *Fake) CallsTo(method string) []Call {
+	var matching []Call
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			matching = append(matching, call)
+		}
+	}
+
+	return matching
+}
+
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+func (f *Fake) record(method string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+func (f *Fake) WhoAmI() (model.WhoAmI, error) {
+	f.record("WhoAmI")
+	if f.WhoAmIFunc == nil {
+		return model.WhoAmI{}, nil
+	}
+
+	return f.WhoAmIFunc()
+}
+
+func (f *Fake) TokenInfo(user model.User) (model.TokenInfo, error) {
+	f.record("TokenInfo", user)
+	if f.TokenInfoFunc == nil {
+		return model.TokenInfo{}, nil
+	}
+
+	return f.TokenInfoFunc(user)
+}
+
+func (f *Fake) Logout() error {
+	f.record("Logout")
+	if f.LogoutFunc == nil {
+		return nil
+	}
+
+	return f.LogoutFunc()
+}
+
+func (f *Fake) LogoutAndForget(store monzo.TokenStore) error {
+	f.record("LogoutAndForget", store)
+	if f.LogoutAndForgetFunc == nil {
+		return nil
+	}
+
+	return f.LogoutAndForgetFunc(store)
+}
+
+func (f *Fake) WaitForApproval(ctx context.Context, pollInterval time.Duration, progress func(monzo.ApprovalProgress)) error {
+	f.record("WaitForApproval", pollInterval)
+	if f.WaitForApprovalFunc == nil {
+		return nil
+	}
+
+	return f.WaitForApprovalFunc(ctx, pollInterval, progress)
+}
+
+func (f *Fake) Accounts() (model.Monzo, error) {
+	f.record("Accounts")
+	if f.AccountsFunc == nil {
+		return model.Monzo{}, nil
+	}
+
+	return f.AccountsFunc()
+}
+
+func (f *Fake) CurrentAccount() (model.Account, error) {
+	f.record("CurrentAccount")
+	if f.CurrentAccountFunc == nil {
+		return model.Account{}, nil
+	}
+
+	return f.CurrentAccountFunc()
+}
+
+func (f *Fake) Balance(accountID string) (model.Balance, error) {
+	f.record("Balance", accountID)
+	if f.BalanceFunc == nil {
+		return model.Balance{}, nil
+	}
+
+	return f.BalanceFunc(accountID)
+}
+
+func (f *Fake) Pots() (model.Monzo, error) {
+	f.record("Pots")
+	if f.PotsFunc == nil {
+		return model.Monzo{}, nil
+	}
+
+	return f.PotsFunc()
+}
+
+func (f *Fake) AccountPots(accountID string) ([]model.Pot, error) {
+	f.record("AccountPots", accountID)
+	if f.AccountPotsFunc == nil {
+		return nil, nil
+	}
+
+	return f.AccountPotsFunc(accountID)
+}
+
+func (f *Fake) Snapshot(ctx context.Context) (model.Snapshot, error) {
+	f.record("Snapshot")
+	if f.SnapshotFunc == nil {
+		return model.Snapshot{}, nil
+	}
+
+	return f.SnapshotFunc(ctx)
+}
+
+func (f *Fake) Transactions(accountID string, params monzo.TransactionParams) ([]model.TransactionData, error) {
+	f.record("Transactions", accountID, params)
+	if f.TransactionsFunc == nil {
+		return nil, nil
+	}
+
+	return f.TransactionsFunc(accountID, params)
+}
+
+func (f *Fake) Withdraw(sourcePotID string, destinationAccountID string, amount int64) (model.Pot, error) {
+	f.record("Withdraw", sourcePotID, destinationAccountID, amount)
+	if f.WithdrawFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.WithdrawFunc(sourcePotID, destinationAccountID, amount)
+}
+
+func (f *Fake) Deposit(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error) {
+	f.record("Deposit", targetPotID, sourceAccountID, amount)
+	if f.DepositFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.DepositFunc(targetPotID, sourceAccountID, amount)
+}
+
+func (f *Fake) WithdrawWithDedupe(sourcePotID string, destinationAccountID string, amount int64, dedupeID string) (model.Pot, error) {
+	f.record("WithdrawWithDedupe", sourcePotID, destinationAccountID, amount, dedupeID)
+	if f.WithdrawDedupeFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.WithdrawDedupeFunc(sourcePotID, destinationAccountID, amount, dedupeID)
+}
+
+func (f *Fake) DepositWithDedupe(targetPotID string, sourceAccountID string, amount int64, dedupeID string) (model.Pot, error) {
+	f.record("DepositWithDedupe", targetPotID, sourceAccountID, amount, dedupeID)
+	if f.DepositDedupeFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.DepositDedupeFunc(targetPotID, sourceAccountID, amount, dedupeID)
+}
+
+func (f *Fake) WithdrawMoney(sourcePot model.Pot, destinationAccountID string, amount model.Money) (model.Pot, error) {
+	f.record("WithdrawMoney", sourcePot, destinationAccountID, amount)
+	if f.WithdrawMoneyFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.WithdrawMoneyFunc(sourcePot, destinationAccountID, amount)
+}
+
+func (f *Fake) DepositMoney(targetPot model.Pot, sourceAccountID string, amount model.Money) (model.Pot, error) {
+	f.record("DepositMoney", targetPot, sourceAccountID, amount)
+	if f.DepositMoneyFunc == nil {
+		return model.Pot{}, nil
+	}
+
+	return f.DepositMoneyFunc(targetPot, sourceAccountID, amount)
+}
+
+func (f *Fake) RegisterWebhook(accountID string) (model.Webhook, error) {
+	f.record("RegisterWebhook", accountID)
+	if f.RegisterWebhookFunc == nil {
+		return model.Webhook{}, nil
+	}
+
+	return f.RegisterWebhookFunc(accountID)
+}
+
+func (f *Fake) RegisterWebhookURL(accountID string, webhookURL string) (model.Webhook, error) {
+	f.record("RegisterWebhookURL", accountID, webhookURL)
+	if f.RegisterWebhookURLFunc == nil {
+		return model.Webhook{}, nil
+	}
+
+	return f.RegisterWebhookURLFunc(accountID, webhookURL)
+}
+
+func (f *Fake) DeleteWebhook(webhookID string) error {
+	f.record("DeleteWebhook", webhookID)
+	if f.DeleteWebhookFunc == nil {
+		return nil
+	}
+
+	return f.DeleteWebhookFunc(webhookID)
+}
+
+func (f *Fake) Webhooks(accountID string) ([]model.Webhook, error) {
+	f.record("Webhooks", accountID)
+	if f.WebhooksFunc == nil {
+		return nil, nil
+	}
+
+	return f.WebhooksFunc(accountID)
+}
+
+func (f *Fake) CreateFeedItem(accountID string, title string, body string, imageURL string) error {
+	f.record("CreateFeedItem", accountID, title, body, imageURL)
+	if f.CreateFeedItemFunc == nil {
+		return nil
+	}
+
+	return f.CreateFeedItemFunc(accountID, title, body, imageURL)
+}
+
+func (f *Fake) PostFeedItem(accountID string, item *monzo.FeedItem) error {
+	f.record("PostFeedItem", accountID, item)
+	if f.PostFeedItemFunc == nil {
+		return nil
+	}
+
+	return f.PostFeedItemFunc(accountID, item)
+}
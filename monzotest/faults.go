@@ -0,0 +1,242 @@
+package monzotest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var ErrResponseLost = errors.New("monzotest: request was applied but the response was lost")
+
+// Fault describes what to do to a matching request. Latency is applied first
+// and may be combined with any one of the other faults.
+type Fault struct {
+	Latency time.Duration
+
+	// Reset fails the request with a connection reset before it is sent.
+	Reset bool
+
+	// StatusCode answers with this status and a Monzo style error body
+	// without sending the request. RetryAfter adds a Retry-After header.
+	StatusCode int
+	RetryAfter time.Duration
+
+	// Truncate sends the request and cuts the response body in half.
+	Truncate bool
+
+	// LoseResponse sends the request, so the server applies it, and then
+	// fails with ErrResponseLost.
+	LoseResponse bool
+
+	// Times limits the fault to the first Times matches; zero means always.
+	Times int
+}
+
+func Latency(d time.Duration) Fault {
+	return Fault{Latency: d}
+}
+
+func ConnectionReset() Fault {
+	return Fault{Reset: true}
+}
+
+func ServerError(statusCode int) Fault {
+	return Fault{StatusCode: statusCode}
+}
+
+func RateLimited(retryAfter time.Duration) Fault {
+	return Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+func TruncatedBody() Fault {
+	return Fault{Truncate: true}
+}
+
+func ResponseLost() Fault {
+	return Fault{LoseResponse: true}
+}
+
+type faultRule struct {
+	method  string
+	pattern string
+	fault   Fault
+	applied int
+}
+
+// FaultTransport is an http.RoundTripper that injects faults into requests
+// whose method and URL path match a rule. Patterns use path.Match syntax, so
+// "/pots/*/deposit" matches every deposit. The first matching rule wins.
+type FaultTransport struct {
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	rules    []*faultRule
+	injected int
+}
+
+func NewFaultTransport(transport http.RoundTripper) *FaultTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &FaultTransport{Transport: transport}
+}
+
+// Inject adds a rule; an empty method matches any method.
+func (f *FaultTransport) Inject(method string, pattern string, fault Fault) *FaultTransport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rules = append(f.rules, &faultRule{method: method, pattern: pattern, fault: fault})
+
+	return f
+}
+
+func (f *FaultTransport) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rules = nil
+}
+
+// Injected counts the requests a fault has been applied to.
+func (f *FaultTransport) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.injected
+}
+
+func (f *FaultTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	fault, ok := f.match(request)
+	if !ok {
+		return f.Transport.RoundTrip(request)
+	}
+
+	if fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+
+	switch {
+	case fault.Reset:
+		return nil, connectionReset(request)
+	case fault.StatusCode != 0:
+		return faultResponse(request, fault), nil
+	case fault.LoseResponse:
+		response, err := f.Transport.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Body.Close()
+
+		return nil, ErrResponseLost
+	case fault.Truncate:
+		response, err := f.Transport.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := readResponseBody(response)
+		if err != nil {
+			return nil, err
+		}
+
+		body = body[:len(body)/2]
+		response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		response.ContentLength = int64(len(body))
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		return response, nil
+	}
+
+	return f.Transport.RoundTrip(request)
+}
+
+func (f *FaultTransport) match(request *http.Request) (Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, rule := range f.rules {
+		if rule.method != "" && rule.method != request.Method {
+			continue
+		}
+
+		if matched, _ := path.Match(rule.pattern, request.URL.Path); !matched {
+			continue
+		}
+
+		if rule.fault.Times > 0 && rule.applied >= rule.fault.Times {
+			continue
+		}
+
+		rule.applied++
+		f.injected++
+
+		return rule.fault, true
+	}
+
+	return Fault{}, false
+}
+
+func connectionReset(request *http.Request) error {
+	return &net.OpError{
+		Op:   "read",
+		Net:  "tcp",
+		Addr: fakeAddr(request.URL.Host),
+		Err:  os.NewSyscallError("read", syscall.ECONNRESET),
+	}
+}
+
+func faultResponse(request *http.Request, fault Fault) *http.Response {
+	code := "internal_service_error"
+	if fault.StatusCode == http.StatusTooManyRequests {
+		code = "too_many_requests"
+	}
+
+	body := fmt.Sprintf(`{"code": %q, "message": "injected fault"}`, code)
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	if fault.RetryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(int((fault.RetryAfter+time.Second-1)/time.Second)))
+	}
+
+	return &http.Response{
+		StatusCode:    fault.StatusCode,
+		Status:        fmt.Sprintf("%d %s", fault.StatusCode, http.StatusText(fault.StatusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
+
+type fakeAddr string
+
+func (a fakeAddr) Network() string {
+	return "tcp"
+}
+
+func (a fakeAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,710 @@
+package monzotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+const (
+	DefaultUserID   = "user_00009"
+	DefaultTokenTTL = 6 * time.Hour
+	DefaultSortCode = "040004"
+
+	maxTransactionsLimit = 100
+)
+
+// webhookClient delivers webhook events, with a timeout so a stuck receiver
+// cannot hang AddTransaction.
+var webhookClient = &http.Client{Timeout: 5 * time.Second}
+
+type FeedItem struct {
+	AccountID string
+	Type      string
+	URL       string
+	Params    map[string]string
+}
+
+type serverToken struct {
+	clientID string
+	expiry   time.Time
+	revoked  bool
+}
+
+type serverCode struct {
+	clientID      string
+	codeChallenge string
+}
+
+type serverDedupe struct {
+	potID string
+	pot   model.Pot
+}
+
+// Server is an in-memory Monzo API for integration tests. It issues and
+// refreshes tokens, moves money between accounts and pots, pages through
+// transactions, delivers webhooks and records feed items. Install points the
+// monzo package at it.
+type Server struct {
+	*httptest.Server
+
+	UserID   string
+	TokenTTL time.Duration
+
+	mu            sync.Mutex
+	sequence      int
+	requests      int
+	tokens        map[string]*serverToken
+	refreshTokens map[string]string
+	codes         map[string]serverCode
+	accounts      []model.Account
+	balances      map[string]*model.Balance
+	pots          []*model.Pot
+	dedupes       map[string]serverDedupe
+	transactions  []model.TransactionData
+	webhooks      []model.Webhook
+	feedItems     []FeedItem
+}
+
+func NewServer() *Server {
+	s := &Server{
+		UserID:        DefaultUserID,
+		TokenTTL:      DefaultTokenTTL,
+		tokens:        make(map[string]*serverToken),
+		refreshTokens: make(map[string]string),
+		codes:         make(map[string]serverCode),
+		balances:      make(map[string]*model.Balance),
+		dedupes:       make(map[string]serverDedupe),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", s.handleAuth)
+	mux.HandleFunc("/oauth2/token", s.handleToken)
+	mux.HandleFunc("/oauth2/logout", s.authorized(s.handleLogout))
+	mux.HandleFunc("/ping/whoami", s.authorized(s.handleWhoAmI))
+	mux.HandleFunc("/accounts", s.authorized(s.handleAccounts))
+	mux.HandleFunc("/balance", s.authorized(s.handleBalance))
+	mux.HandleFunc("/pots", s.authorized(s.handlePots))
+	mux.HandleFunc("/pots/", s.authorized(s.handlePotTransfer))
+	mux.HandleFunc("/transactions", s.authorized(s.handleTransactions))
+	mux.HandleFunc("/webhooks", s.authorized(s.handleWebhooks))
+	mux.HandleFunc("/webhooks/", s.authorized(s.handleWebhookDelete))
+	mux.HandleFunc("/feed", s.authorized(s.handleFeed))
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req_%05d", s.requests))
+		s.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	return s
+}
+
+func (s *Server) Install() {
+	monzo.SetURL(monzo.LoginURL, s.URL+"/auth?client_id=%s&redirect_uri=%s&response_type=code&state=%s")
+	monzo.SetURL(monzo.WhoAmIURL, s.URL+"/ping/whoami")
+	monzo.SetURL(monzo.Oauth2URL, s.URL+"/oauth2/token")
+	monzo.SetURL(monzo.LogoutURL, s.URL+"/oauth2/logout")
+	monzo.SetURL(monzo.AccountsURL, s.URL+"/accounts?account_type=uk_retail")
+	monzo.SetURL(monzo.BalanceURL, s.URL+"/balance?account_id=%s")
+	monzo.SetURL(monzo.PotsURL, s.URL+"/pots")
+	monzo.SetURL(monzo.AccountPotsURL, s.URL+"/pots?current_account_id=%s")
+	monzo.SetURL(monzo.DepositURL, s.URL+"/pots/%s/deposit")
+	monzo.SetURL(monzo.WithdrawURL, s.URL+"/pots/%s/withdraw")
+	monzo.SetURL(monzo.TransactionsURL, s.URL+"/transactions?expand[]=merchant&account_id=%s")
+	monzo.SetURL(monzo.WebhookGetURL, s.URL+"/webhooks?account_id=%s")
+	monzo.SetURL(monzo.WebhookCreateURL, s.URL+"/webhooks")
+	monzo.SetURL(monzo.WebhookDeleteURL, s.URL+"/webhooks/%s")
+	monzo.SetURL(monzo.FeedItemCreateURL, s.URL+"/feed")
+}
+
+func (s *Server) AddAccount(description string, balance int64, currency string) model.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := model.Account{
+		ID:          s.nextID("acc"),
+		Description: description,
+		Created:     time.Now().UTC(),
+		SortCode:    DefaultSortCode,
+	}
+	account.AccountNumber = fmt.Sprintf("%08d", s.sequence)
+
+	s.accounts = append(s.accounts, account)
+	s.balances[account.ID] = &model.Balance{Balance: balance, Currency: currency}
+
+	return account
+}
+
+func (s *Server) AddPot(accountID string, name string, balance int64) model.Pot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pot := &model.Pot{
+		ID:               s.nextID("pot"),
+		Name:             name,
+		Balance:          balance,
+		CurrentAccountID: accountID,
+	}
+
+	if account, ok := s.balances[accountID]; ok {
+		pot.Currency = account.Currency
+	}
+
+	s.pots = append(s.pots, pot)
+
+	return *pot
+}
+
+func (s *Server) DeletePot(potID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pot := s.pot(potID); pot != nil {
+		pot.Deleted = true
+	}
+}
+
+// AddTransaction books a transaction against its account balance, unless it
+// was declined, and delivers a transaction.created event to every webhook on that account
+// before returning.
+func (s *Server) AddTransaction(transaction model.TransactionData) model.TransactionData {
+	s.mu.Lock()
+	transaction, targets := s.book(transaction)
+	s.mu.Unlock()
+
+	deliver(transaction, targets)
+
+	return transaction
+}
+
+func (s *Server) IssueToken() model.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issueToken(monzo.ClientID)
+}
+
+func (s *Server) AuthorizationCode() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := s.nextID("code")
+	s.codes[code] = serverCode{clientID: monzo.ClientID}
+
+	return code
+}
+
+func (s *Server) ExpireToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.tokens[accessToken]; ok {
+		token.expiry = time.Now().Add(-time.Second)
+	}
+}
+
+func (s *Server) FeedItems() []FeedItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]FeedItem, len(s.feedItems))
+	copy(items, s.feedItems)
+
+	return items
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.sequence++
+	return fmt.Sprintf("%s_%05d", prefix, s.sequence)
+}
+
+func (s *Server) issueToken(clientID string) model.User {
+	accessToken := s.nextID("access")
+	refreshToken := s.nextID("refresh")
+
+	s.tokens[accessToken] = &serverToken{clientID: clientID, expiry: time.Now().Add(s.TokenTTL)}
+	s.refreshTokens[refreshToken] = accessToken
+
+	return model.User{
+		UserID:       s.UserID,
+		ClientID:     clientID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.TokenTTL / time.Second),
+		TokenType:    "Bearer",
+	}
+}
+
+func (s *Server) pot(potID string) *model.Pot {
+	for _, pot := range s.pots {
+		if pot.ID == potID {
+			return pot
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) authorized(next func(w http.ResponseWriter, r *http.Request, accessToken string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		token, ok := s.tokens[accessToken]
+		valid := ok && !token.revoked && time.Now().Before(token.expiry)
+		s.mu.Unlock()
+
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_access_token.expired", "Access token has expired")
+			return
+		}
+
+		next(w, r, accessToken)
+	}
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	code := s.nextID("code")
+	s.codes[code] = serverCode{clientID: query.Get("client_id"), codeChallenge: query.Get("code_challenge")}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.redirect_uri", "Invalid redirect_uri")
+		return
+	}
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code, ok := s.codes[r.PostForm.Get("code")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_authorization_code", "Authorization code is invalid or has been used")
+			return
+		}
+
+		delete(s.codes, r.PostForm.Get("code"))
+
+		if code.codeChallenge != "" && monzo.CodeChallenge(r.PostForm.Get("code_verifier")) != code.codeChallenge {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_code_verifier", "Code verifier does not match challenge")
+			return
+		}
+
+		writeJSON(w, s.issueToken(r.PostForm.Get("client_id")))
+	case "refresh_token":
+		accessToken, ok := s.refreshTokens[r.PostForm.Get("refresh_token")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_refresh_token", "Refresh token is invalid or has been used")
+			return
+		}
+
+		delete(s.refreshTokens, r.PostForm.Get("refresh_token"))
+		s.tokens[accessToken].revoked = true
+
+		writeJSON(w, s.issueToken(r.PostForm.Get("client_id")))
+	default:
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.grant_type", "Unsupported grant type")
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	s.tokens[accessToken].revoked = true
+	s.mu.Unlock()
+
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) handleWhoAmI(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, model.WhoAmI{
+		Authenticated: true,
+		ClientID:      s.tokens[accessToken].clientID,
+		UserID:        s.UserID,
+	})
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, model.Monzo{Accounts: s.accounts})
+}
+
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := r.URL.Query().Get("account_id")
+	balance, ok := s.balances[accountID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	result := *balance
+	result.TotalBalance = balance.Balance
+	for _, pot := range s.pots {
+		if pot.CurrentAccountID == accountID && !pot.Deleted {
+			result.TotalBalance += pot.Balance
+		}
+	}
+
+	writeJSON(w, result)
+}
+
+func (s *Server) handlePots(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := r.URL.Query().Get("current_account_id")
+
+	pots := []model.Pot{}
+	for _, pot := range s.pots {
+		if accountID == "" || pot.CurrentAccountID == accountID {
+			pots = append(pots, *pot)
+		}
+	}
+
+	writeJSON(w, model.Monzo{Pots: pots})
+}
+
+// handlePotTransfer serves PUT /pots/{id}/deposit and /pots/{id}/withdraw.
+// A repeated dedupe_id replays the original result without moving money.
+func (s *Server) handlePotTransfer(w http.ResponseWriter, r *http.Request, accessToken string) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/pots/"), "/")
+	if r.Method != http.MethodPut || len(parts) != 2 || (parts[1] != "deposit" && parts[1] != "withdraw") {
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+
+	potID, direction := parts[0], parts[1]
+
+	r.ParseForm()
+
+	amount, err := strconv.ParseInt(r.PostForm.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.amount", "Amount must be a positive integer")
+		return
+	}
+
+	accountID := r.PostForm.Get("source_account_id")
+	if direction == "withdraw" {
+		accountID = r.PostForm.Get("destination_account_id")
+	}
+
+	dedupeID := r.PostForm.Get("dedupe_id")
+
+	s.mu.Lock()
+
+	if previous, ok := s.dedupes[dedupeID]; ok && dedupeID != "" && previous.potID == potID {
+		s.mu.Unlock()
+		writeJSON(w, previous.pot)
+		return
+	}
+
+	pot := s.pot(potID)
+	if pot == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "not_found.pot", "Pot not found")
+		return
+	}
+
+	if pot.Deleted {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "bad_request.pot_deleted", "Pot has been deleted")
+		return
+	}
+
+	balance, ok := s.balances[accountID]
+	if !ok || pot.CurrentAccountID != accountID {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.account_id", "Account does not own this pot")
+		return
+	}
+
+	// The account side moves through a pot transaction, as Monzo lists
+	// transfers in the account's transactions.
+	transaction := model.TransactionData{
+		AccountID:   accountID,
+		Amount:      amount,
+		Description: potID,
+		Category:    "savings",
+		Metadata:    map[string]string{"pot_id": potID},
+	}
+
+	if direction == "deposit" {
+		if balance.Balance < amount {
+			s.mu.Unlock()
+			writeError(w, http.StatusForbidden, "forbidden.insufficient_funds", "Insufficient funds in account")
+			return
+		}
+
+		pot.Balance += amount
+		transaction.Amount = -amount
+	} else {
+		if pot.Balance < amount {
+			s.mu.Unlock()
+			writeError(w, http.StatusForbidden, "forbidden.insufficient_funds", "Insufficient funds in pot")
+			return
+		}
+
+		pot.Balance -= amount
+	}
+
+	transaction, targets := s.book(transaction)
+
+	if dedupeID != "" {
+		s.dedupes[dedupeID] = serverDedupe{potID: potID, pot: *pot}
+	}
+
+	result := *pot
+	s.mu.Unlock()
+
+	deliver(transaction, targets)
+
+	writeJSON(w, result)
+}
+
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request, accessToken string) {
+	query := r.URL.Query()
+
+	limit := maxTransactionsLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxTransactionsLimit {
+			writeError(w, http.StatusBadRequest, "bad_request.bad_param.limit", "Limit must be between 1 and 100")
+			return
+		}
+
+		limit = parsed
+	}
+
+	var before time.Time
+	if raw := query.Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request.bad_param.before", "Before must be an RFC3339 timestamp")
+			return
+		}
+
+		before = parsed
+	}
+
+	since := query.Get("since")
+	sinceTime, sinceErr := time.Parse(time.RFC3339Nano, since)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := query.Get("account_id")
+	if _, ok := s.balances[accountID]; !ok {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	// A since that is not a timestamp is a transaction ID; only transactions
+	// after it are returned.
+	afterID := since != "" && sinceErr != nil
+	seenID := false
+
+	transactions := []model.TransactionData{}
+	for _, transaction := range s.transactions {
+		if afterID && !seenID {
+			seenID = transaction.TransactionID == since
+			continue
+		}
+
+		if transaction.AccountID != accountID {
+			continue
+		}
+
+		if since != "" && sinceErr == nil && transaction.Created.Before(sinceTime) {
+			continue
+		}
+
+		if !before.IsZero() && !transaction.Created.Before(before) {
+			continue
+		}
+
+		transactions = append(transactions, transaction)
+		if len(transactions) == limit {
+			break
+		}
+	}
+
+	writeJSON(w, model.Monzo{Transactions: transactions})
+}
+
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request, accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodPost {
+		r.ParseForm()
+
+		webhook := model.Webhook{
+			ID:        s.nextID("webhook"),
+			AccountID: r.PostForm.Get("account_id"),
+			URL:       r.PostForm.Get("url"),
+		}
+
+		s.webhooks = append(s.webhooks, webhook)
+
+		writeJSON(w, model.Monzo{Webhook: webhook})
+		return
+	}
+
+	accountID := r.URL.Query().Get("account_id")
+
+	webhooks := []model.Webhook{}
+	for _, webhook := range s.webhooks {
+		if webhook.AccountID == accountID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	writeJSON(w, model.Monzo{Webhooks: webhooks})
+}
+
+func (s *Server) handleWebhookDelete(w http.ResponseWriter, r *http.Request, accessToken string) {
+	webhookID := strings.TrimPrefix(r.URL.Path, "/webhooks/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, webhook := range s.webhooks {
+		if webhook.ID == webhookID && r.Method == http.MethodDelete {
+			s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+			writeJSON(w, struct{}{})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "not_found.webhook", "Webhook not found")
+}
+
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request, accessToken string) {
+	r.ParseForm()
+
+	item := FeedItem{
+		AccountID: r.PostForm.Get("account_id"),
+		Type:      r.PostForm.Get("type"),
+		URL:       r.PostForm.Get("url"),
+		Params:    make(map[string]string),
+	}
+
+	for key := range r.PostForm {
+		if strings.HasPrefix(key, "params[") && strings.HasSuffix(key, "]") {
+			item.Params[key[len("params["):len(key)-1]] = r.PostForm.Get(key)
+		}
+	}
+
+	s.mu.Lock()
+	s.feedItems = append(s.feedItems, item)
+	s.mu.Unlock()
+
+	writeJSON(w, struct{}{})
+}
+
+// book records transaction against its account balance and returns the
+// webhooks to deliver it to. s.mu must be held.
+func (s *Server) book(transaction model.TransactionData) (model.TransactionData, []string) {
+	if transaction.TransactionID == "" {
+		transaction.TransactionID = s.nextID("tx")
+	}
+
+	if transaction.Created.IsZero() {
+		transaction.Created = time.Now().UTC()
+	}
+
+	if balance, ok := s.balances[transaction.AccountID]; ok {
+		if !transaction.Declined() {
+			balance.Balance += transaction.Amount
+			if transaction.PotID() == "" {
+				balance.SpendToday += spend(transaction.Amount)
+			}
+		}
+
+		if transaction.Currency == "" {
+			transaction.Currency = balance.Currency
+		}
+	}
+
+	s.transactions = append(s.transactions, transaction)
+	sort.SliceStable(s.transactions, func(i, j int) bool {
+		return s.transactions[i].Created.Before(s.transactions[j].Created)
+	})
+
+	var targets []string
+	for _, webhook := range s.webhooks {
+		if webhook.AccountID == transaction.AccountID {
+			targets = append(targets, webhook.URL)
+		}
+	}
+
+	return transaction, targets
+}
+
+// deliver posts a transaction.created event to each target, ignoring
+// failures as Monzo would retry them later.
+func deliver(transaction model.TransactionData, targets []string) {
+	event, _ := json.Marshal(model.Transaction{Type: "transaction.created", Data: transaction})
+	for _, target := range targets {
+		if response, err := webhookClient.Post(target, "application/json", bytes.NewReader(event)); err == nil {
+			response.Body.Close()
+		}
+	}
+}
+
+func spend(amount int64) int64 {
+	if amount < 0 {
+		return amount
+	}
+
+	return 0
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
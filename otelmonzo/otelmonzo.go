@@ -0,0 +1,215 @@
+// Package otelmonzo instruments the monzo client with OpenTelemetry spans and
+// metrics. It only depends on the OTel API; providers come from Config or the
+// otel globals.
+//
+// Spans are children of the span in the request's context, so send requests
+// with client.WithContext(ctx) to join the caller's trace.
+package otelmonzo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/gurparit/go-monzo/otelmonzo"
+
+const (
+	attrOperation     = attribute.Key("monzo.operation")
+	attrAccountIDHash = attribute.Key("monzo.account_id_hash")
+	attrRequestID     = attribute.Key("monzo.request_id")
+	attrRetryCount    = attribute.Key("monzo.retry_count")
+	attrMethod        = attribute.Key("http.request.method")
+	attrStatusCode    = attribute.Key("http.response.status_code")
+	attrOutcome       = attribute.Key("monzo.outcome")
+)
+
+// accountFields are the query and form fields whose values are hashed into
+// the monzo.account_id_hash attribute. Raw account IDs are never recorded.
+var accountFields = []string{"account_id", "current_account_id", "source_account_id", "destination_account_id"}
+
+type Config struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+type Instrumentation struct {
+	tracer trace.Tracer
+
+	requests   metric.Int64Counter
+	duration   metric.Float64Histogram
+	refreshes  metric.Int64Counter
+	rateLimits metric.Int64Counter
+}
+
+func New(config Config) (*Instrumentation, error) {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+
+	if config.MeterProvider == nil {
+		config.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := config.MeterProvider.Meter(instrumentationName)
+
+	requests, err := meter.Int64Counter("monzo.client.requests",
+		metric.WithDescription("Monzo API requests by operation and status code."))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("monzo.client.request.duration",
+		metric.WithDescription("Monzo API request latency."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshes, err := meter.Int64Counter("monzo.client.token.refreshes",
+		metric.WithDescription("OAuth token refreshes by outcome."))
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimits, err := meter.Int64Counter("monzo.client.rate_limits",
+		metric.WithDescription("Responses with status 429 Too Many Requests."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer:     config.TracerProvider.Tracer(instrumentationName),
+		requests:   requests,
+		duration:   duration,
+		refreshes:  refreshes,
+		rateLimits: rateLimits,
+	}, nil
+}
+
+// Option instruments a single client. Add it to a monzo.TokenSource's
+// Options to count the token refreshes it makes too.
+func (i *Instrumentation) Option() monzo.Option {
+	return monzo.WithMiddleware(i.Middleware())
+}
+
+func (i *Instrumentation) Middleware() monzo.Middleware {
+	return func(next monzo.Doer) monzo.Doer {
+		return func(call *monzo.Call) (*http.Response, error) {
+			attrs := []attribute.KeyValue{
+				attrOperation.String(call.Operation),
+				attrMethod.String(call.Request.Method),
+			}
+
+			spanAttrs := append([]attribute.KeyValue{attrRetryCount.Int(RetryCount(call.Request.Context()))}, attrs...)
+			if accountID := accountID(call.Request); accountID != "" {
+				spanAttrs = append(spanAttrs, attrAccountIDHash.String(hashAccountID(accountID)))
+			}
+
+			ctx, span := i.tracer.Start(call.Request.Context(), call.Operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(spanAttrs...))
+			defer span.End()
+
+			call.Request = call.Request.WithContext(ctx)
+
+			start := time.Now()
+			response, err := next(call)
+			elapsed := time.Since(start).Seconds()
+
+			status := 0
+			if response != nil {
+				status = response.StatusCode
+				attrs = append(attrs, attrStatusCode.Int(status))
+				span.SetAttributes(attrStatusCode.Int(status))
+
+				if requestID := response.Header.Get("X-Request-Id"); requestID != "" {
+					span.SetAttributes(attrRequestID.String(requestID))
+				}
+			}
+
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case status >= 400:
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			i.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+			i.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+
+			if status == http.StatusTooManyRequests {
+				i.rateLimits.Add(ctx, 1, metric.WithAttributes(attrOperation.String(call.Operation)))
+			}
+
+			if call.Operation == monzo.OpTokenRefresh {
+				outcome := "success"
+				if err != nil || status < 200 || status > 299 {
+					outcome = "failure"
+				}
+
+				i.refreshes.Add(ctx, 1, metric.WithAttributes(attrOutcome.String(outcome)))
+			}
+
+			return response, err
+		}
+	}
+}
+
+type retryCountKey struct{}
+
+// WithRetryCount marks a request as the nth retry. Retry middleware placed
+// before the instrumentation sets it on call.Request so spans carry it.
+func WithRetryCount(ctx context.Context, count int) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, count)
+}
+
+func RetryCount(ctx context.Context) int {
+	count, _ := ctx.Value(retryCountKey{}).(int)
+	return count
+}
+
+// accountID finds the account a request is about in its query or form body.
+// The body is read through GetBody so the request itself is left untouched.
+func accountID(request *http.Request) string {
+	values := request.URL.Query()
+
+	if request.GetBody != nil && strings.HasPrefix(request.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if body, err := request.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(body)
+			body.Close()
+
+			if form, err := url.ParseQuery(string(data)); err == nil {
+				for key, value := range form {
+					values[key] = append(values[key], value...)
+				}
+			}
+		}
+	}
+
+	for _, field := range accountFields {
+		if value := values.Get(field); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func hashAccountID(accountID string) string {
+	sum := sha256.Sum256([]byte(accountID))
+	return hex.EncodeToString(sum[:8])
+}
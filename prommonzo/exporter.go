@@ -0,0 +1,210 @@
+// Package prommonzo exports Monzo balances and pots as Prometheus metrics.
+package prommonzo
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "monzo"
+
+const (
+	StageToken    = "token"
+	StageAccounts = "accounts"
+	StageBalance  = "balance"
+	StagePots     = "pots"
+)
+
+// ClientFunc returns a client with a valid token. It is called once per
+// refresh so tokens can be renewed between scrapes.
+type ClientFunc func() (monzo.API, error)
+
+// TokenSourceClient adapts a TokenSource, which refreshes tokens as they near
+// expiry, to a ClientFunc.
+func TokenSourceClient(ts *monzo.TokenSource) ClientFunc {
+	return func() (monzo.API, error) {
+		return ts.Client()
+	}
+}
+
+// Exporter polls Monzo and holds the latest values in gauges. Amounts are in
+// major currency units, e.g. pounds rather than pence.
+type Exporter struct {
+	Client  ClientFunc
+	OnError func(stage string, err error)
+
+	accountBalance *prometheus.GaugeVec
+	totalBalance   *prometheus.GaugeVec
+	spendToday     *prometheus.GaugeVec
+	potBalance     *prometheus.GaugeVec
+	lastSuccess    prometheus.Gauge
+	errors         *prometheus.CounterVec
+}
+
+func NewExporter(client ClientFunc, registerer prometheus.Registerer) (*Exporter, error) {
+	accountLabels := []string{"account", "currency"}
+
+	e := &Exporter{
+		Client: client,
+		accountBalance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "account_balance",
+			Help:      "Available balance of the account in major currency units.",
+		}, accountLabels),
+		totalBalance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "account_total_balance",
+			Help:      "Balance of the account including pots in major currency units.",
+		}, accountLabels),
+		spendToday: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "account_spend_today",
+			Help:      "Amount spent from the account today in major currency units, negative for spend.",
+		}, accountLabels),
+		potBalance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pot_balance",
+			Help:      "Balance of the pot in major currency units.",
+		}, []string{"account", "pot_id", "pot", "currency"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_refresh_success_timestamp_seconds",
+			Help:      "Unix time of the last refresh that completed without errors.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scrape_errors_total",
+			Help:      "Errors while refreshing metrics from Monzo, by stage.",
+		}, []string{"stage"}),
+	}
+
+	for _, stage := range []string{StageToken, StageAccounts, StageBalance, StagePots} {
+		e.errors.WithLabelValues(stage)
+	}
+
+	collectors := []prometheus.Collector{e.accountBalance, e.totalBalance, e.spendToday, e.potBalance, e.lastSuccess, e.errors}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// Refresh fetches accounts, balances and pots once. A failed balance or pot
+// list does not stop the other accounts from updating; the first error is
+// returned.
+func (e *Exporter) Refresh() error {
+	client, err := e.Client()
+	if err != nil {
+		return e.fail(StageToken, err)
+	}
+
+	accounts, err := client.Accounts()
+	if err != nil {
+		return e.fail(StageAccounts, err)
+	}
+
+	var firstErr error
+	record := func(stage string, err error) {
+		if wrapped := e.fail(stage, err); firstErr == nil {
+			firstErr = wrapped
+		}
+	}
+
+	balances := make(map[string]model.Balance)
+	for _, account := range accounts.Accounts {
+		balance, err := client.Balance(account.ID)
+		if err != nil {
+			record(StageBalance, err)
+			continue
+		}
+
+		balances[account.ID] = balance
+	}
+
+	pots := make(map[string][]model.Pot)
+	for _, account := range accounts.Accounts {
+		accountPots, err := client.AccountPots(account.ID)
+		if err != nil {
+			record(StagePots, err)
+			continue
+		}
+
+		pots[account.ID] = accountPots
+	}
+
+	// Gauges are rebuilt from scratch so closed accounts and deleted pots
+	// stop being exported, unless a fetch failed and the last values are all
+	// there is.
+	if len(balances) == len(accounts.Accounts) {
+		e.accountBalance.Reset()
+		e.totalBalance.Reset()
+		e.spendToday.Reset()
+	}
+
+	for accountID, balance := range balances {
+		e.accountBalance.WithLabelValues(accountID, balance.Currency).Set(major(balance.Money()))
+		e.totalBalance.WithLabelValues(accountID, balance.Currency).Set(major(balance.TotalMoney()))
+		e.spendToday.WithLabelValues(accountID, balance.Currency).Set(major(balance.SpendTodayMoney()))
+	}
+
+	if len(pots) == len(accounts.Accounts) {
+		e.potBalance.Reset()
+	}
+
+	for accountID, accountPots := range pots {
+		e.potBalance.DeletePartialMatch(prometheus.Labels{"account": accountID})
+
+		for _, pot := range accountPots {
+			if pot.Deleted {
+				continue
+			}
+
+			e.potBalance.WithLabelValues(accountID, pot.ID, pot.Name, pot.Currency).Set(major(pot.Money()))
+		}
+	}
+
+	if firstErr == nil {
+		e.lastSuccess.SetToCurrentTime()
+	}
+
+	return firstErr
+}
+
+// Run refreshes immediately and then every interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.Refresh()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Exporter) fail(stage string, err error) error {
+	e.errors.WithLabelValues(stage).Inc()
+
+	if e.OnError != nil {
+		e.OnError(stage, err)
+	}
+
+	return errors.Wrapf(err, "monzo exporter %s", stage)
+}
+
+func major(money model.Money) float64 {
+	return float64(money.Amount) / math.Pow10(model.CurrencyExponent(money.Currency))
+}
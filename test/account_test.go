@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"fmt"
+	"strings"
 
 	"github.com/gurparit/go-monzo/model"
 	"github.com/gurparit/go-monzo/monzo"
@@ -206,3 +207,66 @@ func TestAccountPots(t *testing.T) {
 	IsEqual(t, "deleted pot.currency", expectedDeletedPot.Currency, actualDeletedPot.Currency)
 	IsEqual(t, "deleted pot.deleted", expectedDeletedPot.Deleted, actualDeletedPot.Deleted)
 }
+
+func TestAccountBalanceAbroad(t *testing.T) {
+	sampleBalance := `
+{
+	"balance": 5000,
+	"total_balance": 6000,
+	"balance_including_flexible_savings": 106000,
+	"currency": "GBP",
+	"spend_today": -4000,
+	"local_currency": "EUR",
+	"local_exchange_rate": 1.16,
+	"local_spend": [
+		{"spend_today": -2320, "currency": "EUR"},
+		{"spend_today": -2000, "currency": "GBP"}
+	]
+}
+`
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Method", http.MethodGet, r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(sampleBalance))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL)
+
+	balance, err := monzo.New("Bearer", "x-access-token").Balance("x-account-id")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "balance_including_flexible_savings", "£1060.00", balance.FlexibleSavingsMoney().String())
+	IsEqual(t, "local_currency", "EUR", balance.LocalCurrency)
+	IsEqual(t, "local_exchange_rate", model.ExchangeRate(1.16), balance.LocalExchangeRate)
+	IsEqual(t, "is abroad", true, balance.IsAbroad())
+
+	local, err := balance.ToLocal(model.NewMoney(-2000, "GBP"))
+	IsEqual(t, "to local error", nil, err)
+	IsEqual(t, "to local", model.NewMoney(-2320, "EUR"), local)
+
+	summary := balance.SpendTodaySummary()
+	IsEqual(t, "summary.total", model.NewMoney(-4000, "GBP"), summary.Total)
+	IsEqual(t, "summary.by_currency", []model.Money{model.NewMoney(-2320, "EUR"), model.NewMoney(-2000, "GBP")}, summary.ByCurrency)
+	IsEqual(t, "summary", "-£40.00 (-€23.20, -£20.00)", summary.String())
+}
+
+func TestAccountBalanceAtHome(t *testing.T) {
+	var balance model.Balance
+	err := json.Unmarshal([]byte(`{"balance": 100, "currency": "GBP", "spend_today": -150, "local_currency": "", "local_exchange_rate": "", "local_spend": []}`), &balance)
+	IsEqual(t, "error", nil, err)
+
+	IsEqual(t, "is abroad", false, balance.IsAbroad())
+	IsEqual(t, "local_exchange_rate", model.ExchangeRate(0), balance.LocalExchangeRate)
+	IsEqual(t, "summary", "-£1.50", balance.SpendTodaySummary().String())
+
+	response, err := json.Marshal(balance)
+	IsEqual(t, "marshal error", nil, err)
+	IsEqual(t, "round trip", true, strings.Contains(string(response), `"local_exchange_rate":""`))
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+const sampleInsufficientPermissions = `
+{
+	"code": "forbidden.insufficient_permissions",
+	"message": "Access forbidden due to insufficient permissions"
+}
+`
+
+func TestApprovalGranted(t *testing.T) {
+	calls := 0
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		if calls < 3 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(sampleInsufficientPermissions))
+			return
+		}
+
+		w.Write([]byte(`{"accounts": []}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL)
+
+	var updates []monzo.ApprovalProgress
+	err := monzo.New("Bearer", "x-access-token").WaitForApproval(context.Background(), time.Millisecond, func(p monzo.ApprovalProgress) {
+		updates = append(updates, p)
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "count(updates)", 3, len(updates))
+	IsEqual(t, "first.approved", false, updates[0].Approved)
+	IsEqual(t, "first.insufficient_permissions", true, monzo.IsInsufficientPermissions(updates[0].Err))
+	IsEqual(t, "last.attempt", 3, updates[2].Attempt)
+	IsEqual(t, "last.approved", true, updates[2].Approved)
+}
+
+func TestApprovalTimeoutFail(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(sampleInsufficientPermissions))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	err := monzo.New("Bearer", "x-access-token").WaitForApproval(ctx, 5*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestIsInsufficientPermissionsMatchesCode(t *testing.T) {
+	IsEqual(t, "code", true, monzo.IsInsufficientPermissions(&monzo.Error{Code: "forbidden.insufficient_permissions"}))
+	IsEqual(t, "sub-code", true, monzo.IsInsufficientPermissions(&monzo.Error{Code: "forbidden.insufficient_permissions.sca"}))
+	IsEqual(t, "message only", false, monzo.IsInsufficientPermissions(&monzo.Error{
+		Code:    "bad_request",
+		Message: "not forbidden.insufficient_permissions",
+		Body:    `{"code": "bad_request", "message": "not forbidden.insufficient_permissions"}`,
+	}))
+	IsEqual(t, "other error", false, monzo.IsInsufficientPermissions(errors.New("forbidden.insufficient_permissions")))
+}
+
+func TestApprovalUnexpectedErrorFail(t *testing.T) {
+	badAccessToken := `
+{
+	"code": "unauthorized.bad_access_token.expired",
+	"message": "Access token has expired"
+}
+`
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(badAccessToken))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL)
+
+	err := monzo.New("Bearer", "x-access-token").WaitForApproval(context.Background(), time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "unauthorized.bad_access_token.expired") {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,227 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func oauthServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Test Form
+		IsEqual(t, "Method", http.MethodPost, r.Method)
+		IsEqual(t, "grant_type", "authorization_code", r.PostFormValue("grant_type"))
+		IsEqual(t, "code", "x-code", r.PostFormValue("code"))
+
+		data := map[string]interface{}{
+			"user_id":       "x-user-id",
+			"client_id":     "x-client-id",
+			"access_token":  "x-access-token",
+			"refresh_token": "x-refresh-token",
+			"token_type":    "Bearer",
+			"expires_in":    21600,
+		}
+
+		response, err := json.Marshal(data)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+}
+
+func startAuth(t *testing.T, flow monzo.AuthFlow) (string, []*http.Cookie) {
+	recorder := httptest.NewRecorder()
+	flow.StartHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	IsEqual(t, "status", http.StatusFound, recorder.Code)
+
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	state := location.Query().Get("state")
+	IsEqual(t, "has state", true, len(state) >= 32)
+	IsEqual(t, "response_type", "code", location.Query().Get("response_type"))
+
+	return state, recorder.Result().Cookies()
+}
+
+func callbackAuth(flow monzo.AuthFlow, state string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	query := url.Values{}
+	query.Set("code", "x-code")
+	query.Set("state", state)
+
+	request := httptest.NewRequest(http.MethodGet, "/callback?"+query.Encode(), nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	flow.CallbackHandler().ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestAuthFlowMemoryStore(t *testing.T) {
+	testHttp := oauthServer(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	var user model.User
+	flow := monzo.NewAuthFlow(monzo.NewMemoryStateStore(0), func(w http.ResponseWriter, r *http.Request, u model.User) {
+		user = u
+	})
+
+	state, _ := startAuth(t, flow)
+
+	recorder := callbackAuth(flow, state, nil)
+	IsEqual(t, "status", http.StatusOK, recorder.Code)
+	IsEqual(t, "user.access_token", "x-access-token", user.AccessToken)
+
+	// States are single use.
+	recorder = callbackAuth(flow, state, nil)
+	IsEqual(t, "replay status", http.StatusBadRequest, recorder.Code)
+}
+
+func TestAuthFlowCookieStore(t *testing.T) {
+	testHttp := oauthServer(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	var user model.User
+	flow := monzo.NewAuthFlow(monzo.NewCookieStateStore([]byte("x-signing-key")), func(w http.ResponseWriter, r *http.Request, u model.User) {
+		user = u
+	})
+
+	state, cookies := startAuth(t, flow)
+	IsEqual(t, "count(cookies)", 1, len(cookies))
+
+	recorder := callbackAuth(flow, state, cookies)
+	IsEqual(t, "status", http.StatusOK, recorder.Code)
+	IsEqual(t, "user.refresh_token", "x-refresh-token", user.RefreshToken)
+}
+
+func TestMemoryStateStoreZeroValue(t *testing.T) {
+	var store monzo.MemoryStateStore
+
+	err := store.Save(nil, nil, "x-state", "x-verifier")
+	IsEqual(t, "save error", nil, err)
+
+	verifier, err := store.Verify(nil, nil, "x-state")
+	IsEqual(t, "verify error", nil, err)
+	IsEqual(t, "verifier", "x-verifier", verifier)
+}
+
+func TestAuthFlowStateMismatchFail(t *testing.T) {
+	stores := map[string]monzo.StateStore{
+		"memory": monzo.NewMemoryStateStore(0),
+		"cookie": monzo.NewCookieStateStore([]byte("x-signing-key")),
+	}
+
+	for name, store := range stores {
+		var failure error
+		flow := monzo.AuthFlow{
+			Store: store,
+			OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+				failure = err
+			},
+		}
+
+		_, cookies := startAuth(t, flow)
+		callbackAuth(flow, "x-forged-state", cookies)
+
+		IsEqual(t, name+" error", monzo.ErrInvalidState, failure)
+	}
+}
+
+func TestAuthFlowTamperedCookieFail(t *testing.T) {
+	store := monzo.NewCookieStateStore([]byte("x-signing-key"))
+	flow := monzo.AuthFlow{Store: store}
+
+	state, _ := startAuth(t, flow)
+
+	forger := monzo.NewCookieStateStore([]byte("x-other-key"))
+	forged := httptest.NewRecorder()
+	forger.Save(forged, nil, state, "")
+
+	recorder := callbackAuth(flow, state, forged.Result().Cookies())
+	IsEqual(t, "status", http.StatusBadRequest, recorder.Code)
+	IsEqual(t, "body", true, strings.Contains(recorder.Body.String(), monzo.ErrInvalidState.Error()))
+}
+
+func TestAuthFlowPKCE(t *testing.T) {
+	var challenge string
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier := r.PostFormValue("code_verifier")
+		IsEqual(t, "has code_verifier", true, len(verifier) >= 43)
+		IsEqual(t, "code_challenge", challenge, monzo.CodeChallenge(verifier))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "x-access-token", "token_type": "Bearer", "expires_in": 21600}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	stores := map[string]monzo.StateStore{
+		"memory": monzo.NewMemoryStateStore(0),
+		"cookie": monzo.NewCookieStateStore([]byte("x-signing-key")),
+	}
+
+	for name, store := range stores {
+		var user model.User
+		flow := monzo.AuthFlow{
+			Store: store,
+			PKCE:  true,
+			OnSuccess: func(w http.ResponseWriter, r *http.Request, u model.User) {
+				user = u
+			},
+		}
+
+		recorder := httptest.NewRecorder()
+		flow.StartHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+		location, _ := url.Parse(recorder.Header().Get("Location"))
+		challenge = location.Query().Get("code_challenge")
+
+		IsEqual(t, name+" code_challenge_method", "S256", location.Query().Get("code_challenge_method"))
+		IsEqual(t, name+" has code_challenge", true, challenge != "")
+
+		recorder = callbackAuth(flow, location.Query().Get("state"), recorder.Result().Cookies())
+		IsEqual(t, name+" status", http.StatusOK, recorder.Code)
+		IsEqual(t, name+" user.access_token", "x-access-token", user.AccessToken)
+	}
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	IsEqual(t, "challenge", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", monzo.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, challenge, err := monzo.GeneratePKCE()
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "generated challenge", monzo.CodeChallenge(verifier), challenge)
+
+	login, _ := url.Parse(monzo.LoginPKCE("x-state", challenge))
+	IsEqual(t, "login code_challenge", challenge, login.Query().Get("code_challenge"))
+
+	login, _ = url.Parse(monzo.Login("x-state"))
+	IsEqual(t, "login without pkce", "", login.Query().Get("code_challenge"))
+}
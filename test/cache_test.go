@@ -0,0 +1,275 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+type hitCounter struct {
+	whoami   int32
+	accounts int32
+	balance  int32
+	pots     int32
+	deposit  int32
+}
+
+func cacheServer(t *testing.T, hits *hitCounter, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/whoami":
+			atomic.AddInt32(&hits.whoami, 1)
+			w.Write([]byte(`{"authenticated": true, "user_id": "x-user-id"}`))
+		case "/accounts":
+			atomic.AddInt32(&hits.accounts, 1)
+			w.Write([]byte(`{"accounts": [{"id": "x-account-id"}]}`))
+		case "/balance":
+			atomic.AddInt32(&hits.balance, 1)
+			w.Write([]byte(`{"balance": 5000, "currency": "GBP"}`))
+		case "/pots":
+			atomic.AddInt32(&hits.pots, 1)
+			w.Write([]byte(`{"pots": [{"id": "x-pot-id", "balance": 100, "currency": "GBP"}]}`))
+		case "/deposit":
+			atomic.AddInt32(&hits.deposit, 1)
+			w.Write([]byte(`{"id": "x-pot-id", "balance": 600, "currency": "GBP"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": "not_found"}`))
+		}
+	}))
+}
+
+func useCacheServer(testHttp *httptest.Server) {
+	monzo.SetURL(monzo.WhoAmIURL, testHttp.URL+"/whoami")
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL+"/accounts")
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"/balance?account_id=%s")
+	monzo.SetURL(monzo.PotsURL, testHttp.URL+"/pots")
+	monzo.SetURL(monzo.AccountPotsURL, testHttp.URL+"/pots?current_account_id=%s")
+	monzo.SetURL(monzo.DepositURL, testHttp.URL+"/deposit?pot=%s")
+}
+
+func TestCacheServesRepeatedReads(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 0)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	for i := 0; i < 3; i++ {
+		_, err := cached.Accounts()
+		IsEqual(t, "accounts error", nil, err)
+
+		account, err := cached.CurrentAccount()
+		IsEqual(t, "current account error", nil, err)
+		IsEqual(t, "current account", "x-account-id", account.ID)
+
+		_, err = cached.Balance("x-account-id")
+		IsEqual(t, "balance error", nil, err)
+	}
+
+	IsEqual(t, "accounts hits", int32(1), atomic.LoadInt32(&hits.accounts))
+	IsEqual(t, "balance hits", int32(1), atomic.LoadInt32(&hits.balance))
+
+	cached.Invalidate()
+
+	cached.Accounts()
+	IsEqual(t, "accounts hits after invalidate", int32(2), atomic.LoadInt32(&hits.accounts))
+}
+
+func TestCacheCollapsesConcurrentReads(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 20*time.Millisecond)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := cached.Pots()
+			IsEqual(t, "pots error", nil, err)
+			IsEqual(t, "count(pots)", 1, len(result.Pots))
+		}()
+	}
+
+	wg.Wait()
+
+	IsEqual(t, "pots hits", int32(1), atomic.LoadInt32(&hits.pots))
+}
+
+func TestCacheInvalidatedByDeposit(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 0)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	cached.Accounts()
+	cached.Pots()
+	cached.AccountPots("x-account-id")
+	cached.Balance("x-account-id")
+
+	_, err := cached.Deposit("x-pot-id", "x-account-id", 500)
+	IsEqual(t, "deposit error", nil, err)
+
+	cached.Accounts()
+	cached.Pots()
+	cached.AccountPots("x-account-id")
+	cached.Balance("x-account-id")
+
+	IsEqual(t, "accounts hits", int32(1), atomic.LoadInt32(&hits.accounts))
+	IsEqual(t, "pots hits", int32(4), atomic.LoadInt32(&hits.pots))
+	IsEqual(t, "balance hits", int32(2), atomic.LoadInt32(&hits.balance))
+}
+
+func TestCacheDepositDuringFetch(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var balanceHits int32
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/balance":
+			if atomic.AddInt32(&balanceHits, 1) == 1 {
+				close(started)
+				<-release
+				w.Write([]byte(`{"balance": 100, "currency": "GBP"}`))
+				return
+			}
+
+			w.Write([]byte(`{"balance": 50, "currency": "GBP"}`))
+		case "/deposit":
+			w.Write([]byte(`{"id": "x-pot-id", "balance": 50, "currency": "GBP"}`))
+		}
+	}))
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	stale := make(chan model.Balance)
+	go func() {
+		balance, _ := cached.Balance("x-account-id")
+		stale <- balance
+	}()
+
+	<-started
+
+	_, err := cached.Deposit("x-pot-id", "x-account-id", 50)
+	IsEqual(t, "deposit error", nil, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	balance, err := cached.Balance("x-account-id")
+	IsEqual(t, "balance error", nil, err)
+	IsEqual(t, "balance after deposit", int64(50), balance.Balance)
+	IsEqual(t, "balance before deposit", int64(100), (<-stale).Balance)
+
+	balance, err = cached.Balance("x-account-id")
+	IsEqual(t, "cached balance error", nil, err)
+	IsEqual(t, "cached balance", int64(50), balance.Balance)
+	IsEqual(t, "balance hits", int32(2), atomic.LoadInt32(&balanceHits))
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 0)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"/missing?account_id=%s")
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	_, err := cached.Balance("x-account-id")
+	IsEqual(t, "first error", true, err != nil)
+
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"/balance?account_id=%s")
+
+	balance, err := cached.Balance("x-account-id")
+	IsEqual(t, "second error", nil, err)
+	IsEqual(t, "balance", int64(5000), balance.Balance)
+}
+
+func TestCacheServesSnapshotAndTokenInfo(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 0)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+
+	err := cached.WaitForApproval(context.Background(), time.Millisecond, nil)
+	IsEqual(t, "approval error", nil, err)
+
+	for i := 0; i < 2; i++ {
+		snapshot, err := cached.Snapshot(context.Background())
+		IsEqual(t, "snapshot error", nil, err)
+		IsEqual(t, "snapshot balance", int64(5000), snapshot.Accounts[0].Balance.Balance)
+
+		info, err := cached.TokenInfo(model.User{})
+		IsEqual(t, "token info error", nil, err)
+		IsEqual(t, "token info user", "x-user-id", info.UserID)
+	}
+
+	IsEqual(t, "whoami hits", int32(1), atomic.LoadInt32(&hits.whoami))
+	IsEqual(t, "accounts hits", int32(1), atomic.LoadInt32(&hits.accounts))
+	IsEqual(t, "balance hits", int32(1), atomic.LoadInt32(&hits.balance))
+	IsEqual(t, "pots hits", int32(1), atomic.LoadInt32(&hits.pots))
+}
+
+func TestCacheWithContextSharesCache(t *testing.T) {
+	hits := &hitCounter{}
+	testHttp := cacheServer(t, hits, 0)
+
+	defer testHttp.Close()
+
+	useCacheServer(testHttp)
+
+	cached := monzo.NewCached(monzo.New("Bearer", "x-access-token"), monzo.DefaultCacheTTL)
+	cached.Accounts()
+
+	_, err := cached.WithContext(context.Background()).Accounts()
+	IsEqual(t, "accounts error", nil, err)
+	IsEqual(t, "accounts hits", int32(1), atomic.LoadInt32(&hits.accounts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = cached.WithContext(ctx).Balance("x-account-id")
+	IsEqual(t, "cancelled", true, errors.Is(err, context.Canceled))
+}
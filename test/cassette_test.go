@@ -0,0 +1,120 @@
+package test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+func useTransport(transport http.RoundTripper) func() {
+	previous := http.DefaultTransport
+	http.DefaultTransport = transport
+
+	return func() { http.DefaultTransport = previous }
+}
+
+func recordCassette(t *testing.T, path string) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+
+	recorder := monzotest.NewRecorder(path, http.DefaultTransport)
+	restore := useTransport(recorder)
+
+	_, err := monzo.Refresh(server.IssueToken().RefreshToken)
+	IsEqual(t, "refresh error", nil, err)
+
+	_, err = client.Deposit(pot.ID, account.ID, 2500)
+	IsEqual(t, "deposit error", nil, err)
+
+	_, err = client.AccountPots(account.ID)
+	IsEqual(t, "pots error", nil, err)
+
+	restore()
+
+	err = recorder.Save()
+	IsEqual(t, "save error", nil, err)
+}
+
+func TestCassetteRecordRedacts(t *testing.T) {
+	for _, name := range []string{"monzo.json", "monzo.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+
+		secret := monzo.ClientSecret
+		monzo.ClientSecret = "x-client-secret"
+
+		recordCassette(t, path)
+
+		monzo.ClientSecret = secret
+
+		data, err := ioutil.ReadFile(path)
+		IsEqual(t, "read error", nil, err)
+
+		content := string(data)
+		IsEqual(t, name+" redacted", true, strings.Contains(content, "REDACTED"))
+		IsEqual(t, name+" client secret", false, strings.Contains(content, "x-client-secret"))
+		IsEqual(t, name+" access token", false, strings.Contains(content, "access_0"))
+		IsEqual(t, name+" refresh token", false, strings.Contains(content, "refresh_0"))
+	}
+}
+
+func TestCassetteReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monzo.yaml")
+	recordCassette(t, path)
+
+	replayer, err := monzotest.NewReplayer(path)
+	IsEqual(t, "replayer error", nil, err)
+
+	restore := useTransport(replayer)
+
+	defer restore()
+
+	client := monzo.New("Bearer", "x-other-token")
+
+	pot, err := client.Deposit("pot_00004", "acc_00003", 2500)
+	IsEqual(t, "deposit error", nil, err)
+	IsEqual(t, "pot balance", int64(2500), pot.Balance)
+
+	pots, err := client.AccountPots("acc_00003")
+	IsEqual(t, "pots error", nil, err)
+	IsEqual(t, "count(pots)", 1, len(pots))
+
+	IsEqual(t, "count(unused)", 1, len(replayer.Unused()))
+
+	_, err = (&http.Client{Transport: replayer}).Get("https://api.monzo.com/accounts")
+	IsEqual(t, "unmatched error", true, err != nil)
+	IsEqual(t, "unmatched", []string{"GET /accounts"}, replayer.Unmatched())
+}
+
+func TestCassetteRecordKeepsLargeNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"x-access-token","amount":9007199254740993}`))
+	}))
+
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "monzo.json")
+	recorder := monzotest.NewRecorder(path, http.DefaultTransport)
+
+	response, err := (&http.Client{Transport: recorder}).Get(server.URL)
+	IsEqual(t, "get error", nil, err)
+	response.Body.Close()
+
+	err = recorder.Save()
+	IsEqual(t, "save error", nil, err)
+
+	data, err := ioutil.ReadFile(path)
+	IsEqual(t, "read error", nil, err)
+	IsEqual(t, "amount", true, strings.Contains(string(data), "9007199254740993"))
+	IsEqual(t, "access token", false, strings.Contains(string(data), "x-access-token"))
+}
@@ -0,0 +1,297 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/export"
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+func TestExportPages(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 100000, "GBP")
+	other := server.AddAccount("Joint", 100000, "GBP")
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: int64(-100 * (i + 1)), Created: start.Add(time.Duration(i) * time.Hour)})
+		server.AddTransaction(model.TransactionData{AccountID: other.ID, Amount: -1, Created: start.Add(time.Duration(i) * time.Hour)})
+	}
+
+	pages := export.NewPages(client, account.ID, start.Add(time.Hour), start.Add(6*time.Hour))
+	pages.PageSize = 2
+
+	var amounts []int64
+	for pages.Next() {
+		amounts = append(amounts, pages.Transaction().Amount)
+	}
+
+	IsEqual(t, "error", nil, pages.Err())
+	IsEqual(t, "amounts", []int64{-200, -300, -400, -500, -600}, amounts)
+}
+
+func TestExportPagesError(t *testing.T) {
+	fake := &monzotest.Fake{
+		TransactionsFunc: func(accountID string, params monzo.TransactionParams) ([]model.TransactionData, error) {
+			return nil, monzo.ErrTokenInvalid
+		},
+	}
+
+	var buffer bytes.Buffer
+	count, err := export.Export(export.NewPages(fake, "acc_1", time.Time{}, time.Time{}), export.NewJSONLinesWriter(&buffer))
+	IsEqual(t, "count", 0, count)
+	IsEqual(t, "error", monzo.ErrTokenInvalid, err)
+}
+
+func TestExportCSV(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 100000, "GBP")
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	server.AddTransaction(model.TransactionData{
+		TransactionID: "tx_1", AccountID: account.ID, Amount: -1234, Created: created,
+		Description: "PRET, LONDON", Category: "eating_out",
+		Merchant: model.Merchant{Name: "Pret A Manger", Category: "eating_out"},
+	})
+	server.AddTransaction(model.TransactionData{TransactionID: "tx_2", AccountID: account.ID, Amount: 50000, Created: created.Add(time.Hour), Description: "Salary"})
+
+	var buffer bytes.Buffer
+	count, err := export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewCSVWriter(&buffer))
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "count", 2, count)
+
+	expected := "id,created,description,amount,currency,category,merchant,merchant_category\n" +
+		"tx_1,2026-03-01T09:30:00Z,\"PRET, LONDON\",-12.34,GBP,eating_out,Pret A Manger,eating_out\n" +
+		"tx_2,2026-03-01T10:30:00Z,Salary,500.00,GBP,,,\n"
+	IsEqual(t, "csv", expected, buffer.String())
+
+	columns, err := export.CSVColumns("date", "amount")
+	IsEqual(t, "columns error", nil, err)
+
+	buffer.Reset()
+	export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewCSVWriter(&buffer, columns...))
+	IsEqual(t, "custom columns", "date,amount\n2026-03-01,-12.34\n2026-03-01,500.00\n", buffer.String())
+
+	_, err = export.CSVColumns("amount", "x-unknown")
+	IsEqual(t, "unknown column", true, err != nil)
+}
+
+func TestExportCSVDeclineReason(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 100, "GBP")
+	server.AddTransaction(model.TransactionData{TransactionID: "tx_1", AccountID: account.ID, Amount: -5000, DeclineReason: "INSUFFICIENT_FUNDS"})
+
+	columns, err := export.CSVColumns("id", "amount", "decline_reason")
+	IsEqual(t, "columns error", nil, err)
+
+	var buffer bytes.Buffer
+	_, err = export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewCSVWriter(&buffer, columns...))
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "csv", "id,amount,decline_reason\ntx_1,-50.00,INSUFFICIENT_FUNDS\n", buffer.String())
+}
+
+func TestExportJSONLines(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 100000, "GBP")
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -100})
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -200})
+
+	var buffer bytes.Buffer
+	_, err := export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewJSONLinesWriter(&buffer))
+	IsEqual(t, "error", nil, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	IsEqual(t, "lines", 2, len(lines))
+
+	var transaction model.TransactionData
+	err = json.Unmarshal([]byte(lines[1]), &transaction)
+	IsEqual(t, "decode error", nil, err)
+	IsEqual(t, "amount", int64(-200), transaction.Amount)
+}
+
+func statementFixture() (export.Statement, []model.TransactionData) {
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	statement := export.Statement{
+		AccountID: "acc_1",
+		Currency:  "GBP",
+		Start:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Balance:   model.Balance{Balance: 98766, Currency: "GBP"},
+		PotNames:  map[string]string{"pot_1": "Holiday"},
+	}
+
+	transactions := []model.TransactionData{
+		{
+			TransactionID: "tx_1", AccountID: "acc_1", Amount: -1234, Currency: "GBP", Created: created,
+			Settled: "2026-03-02T04:00:00Z", Description: "M&S SIMPLY FOOD", Category: "groceries",
+			Merchant: model.Merchant{Name: "Marks & Spencer"},
+		},
+		{
+			TransactionID: "tx_2", AccountID: "acc_1", Amount: -5000, Currency: "GBP", Created: created.Add(time.Hour),
+			Settled: "2026-03-01T10:30:00Z", Description: "pot_1", Category: "savings",
+			Metadata: map[string]string{"pot_id": "pot_1"},
+		},
+		{
+			TransactionID: "tx_3", AccountID: "acc_1", Amount: -9999, Currency: "GBP", Created: created.Add(2 * time.Hour),
+			Description: "DECLINED SHOP", DeclineReason: "INSUFFICIENT_FUNDS",
+		},
+	}
+
+	return statement, transactions
+}
+
+func TestExportOFX(t *testing.T) {
+	statement, transactions := statementFixture()
+
+	var buffer bytes.Buffer
+	writer := export.NewOFXWriter(&buffer, statement)
+	for _, transaction := range transactions {
+		writer.Write(transaction)
+	}
+
+	err := writer.Flush()
+	IsEqual(t, "flush error", nil, err)
+
+	ofx := buffer.String()
+	IsEqual(t, "header", true, strings.HasPrefix(ofx, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n<?OFX OFXHEADER=\"200\" VERSION=\"220\""))
+
+	expected := "<STMTTRN>\n" +
+		"<TRNTYPE>DEBIT</TRNTYPE>\n" +
+		"<DTPOSTED>20260302040000.000[0:GMT]</DTPOSTED>\n" +
+		"<DTUSER>20260301093000.000[0:GMT]</DTUSER>\n" +
+		"<TRNAMT>-12.34</TRNAMT>\n" +
+		"<FITID>tx_1</FITID>\n" +
+		"<NAME>Marks &amp; Spencer</NAME>\n" +
+		"<MEMO>M&amp;S SIMPLY FOOD groceries</MEMO>\n" +
+		"</STMTTRN>\n"
+	IsEqual(t, "transaction", true, strings.Contains(ofx, expected))
+	IsEqual(t, "pot transfer", true, strings.Contains(ofx, "<TRNTYPE>XFER</TRNTYPE>"))
+	IsEqual(t, "pot name", true, strings.Contains(ofx, "<NAME>Holiday</NAME>"))
+	IsEqual(t, "declined", false, strings.Contains(ofx, "tx_3"))
+	IsEqual(t, "ledger balance", true, strings.Contains(ofx, "<LEDGERBAL>\n<BALAMT>987.66</BALAMT>\n<DTASOF>20260302000000.000[0:GMT]</DTASOF>\n</LEDGERBAL>"))
+	IsEqual(t, "bank id", true, strings.Contains(ofx, "<BANKID>"+export.MonzoSortCode+"</BANKID>\n<ACCTID>acc_1</ACCTID>"))
+
+	decoder := xml.NewDecoder(strings.NewReader(ofx))
+	for {
+		_, err = decoder.Token()
+		if err != nil {
+			break
+		}
+	}
+
+	IsEqual(t, "well formed", io.EOF, err)
+}
+
+func TestExportQIF(t *testing.T) {
+	statement, transactions := statementFixture()
+
+	var buffer bytes.Buffer
+	writer := export.NewQIFWriter(&buffer, statement)
+	for _, transaction := range transactions {
+		writer.Write(transaction)
+	}
+
+	err := writer.Flush()
+	IsEqual(t, "flush error", nil, err)
+
+	expected := "!Type:Bank\n" +
+		"D02/03/2026\nT-12.34\nC*\nPMarks & Spencer\nMM&S SIMPLY FOOD\nLgroceries\n^\n" +
+		"D01/03/2026\nT-50.00\nC*\nPHoliday\nL[Holiday]\n^\n"
+	IsEqual(t, "qif", expected, buffer.String())
+}
+
+func TestExportStatement(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 12345, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 5000)
+
+	end := time.Now().UTC().Add(-time.Hour)
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -1000, Created: end.Add(-time.Hour)})
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -345, Created: end.Add(time.Minute)})
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -500, Created: end.Add(2 * time.Minute), DeclineReason: "INSUFFICIENT_FUNDS"})
+
+	statement, err := export.NewStatement(client, account.ID, time.Time{}, time.Time{})
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "currency", "GBP", statement.Currency)
+	IsEqual(t, "balance", int64(11000), statement.Balance.Balance)
+	IsEqual(t, "pot names", map[string]string{pot.ID: "Holiday"}, statement.PotNames)
+	IsEqual(t, "account number", account.AccountNumber, statement.AccountNumber)
+	IsEqual(t, "bank id", monzotest.DefaultSortCode, statement.BankID)
+
+	statement, err = export.NewStatement(client, account.ID, time.Time{}, end)
+	IsEqual(t, "error at end", nil, err)
+	IsEqual(t, "end", end, statement.End)
+	IsEqual(t, "balance at end", int64(11345), statement.Balance.Balance)
+}
+
+func TestExportOFXAccountIDLimit(t *testing.T) {
+	statement, _ := statementFixture()
+	statement.AccountID = "acc_00009AbCdEfGhIjKlMnOpQrSt"
+
+	var buffer bytes.Buffer
+	writer := export.NewOFXWriter(&buffer, statement)
+	err := writer.Flush()
+	IsEqual(t, "flush error", nil, err)
+	IsEqual(t, "acctid", true, strings.Contains(buffer.String(), "<ACCTID>acc_00009AbCdEfGhIjKlM</ACCTID>"))
+}
+
+func TestExportPotTransfersEndToEnd(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -1234, Description: "Coffee"})
+
+	_, err := client.Deposit(pot.ID, account.ID, 5000)
+	IsEqual(t, "deposit error", nil, err)
+
+	_, err = client.Withdraw(pot.ID, account.ID, 1000)
+	IsEqual(t, "withdraw error", nil, err)
+
+	balance, err := client.Balance(account.ID)
+	IsEqual(t, "balance error", nil, err)
+	IsEqual(t, "balance", int64(10000-1234-5000+1000), balance.Balance)
+	IsEqual(t, "spend today", int64(-1234), balance.SpendToday)
+
+	statement, err := export.NewStatement(client, account.ID, time.Time{}, time.Time{})
+	IsEqual(t, "statement error", nil, err)
+
+	var ofx bytes.Buffer
+	_, err = export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewOFXWriter(&ofx, statement))
+	IsEqual(t, "ofx error", nil, err)
+	IsEqual(t, "ofx account", true, strings.Contains(ofx.String(), "<BANKID>"+monzotest.DefaultSortCode+"</BANKID>\n<ACCTID>"+account.AccountNumber+"</ACCTID>"))
+	IsEqual(t, "ofx transfers", 2, strings.Count(ofx.String(), "<TRNTYPE>XFER</TRNTYPE>"))
+	IsEqual(t, "ofx pot name", 2, strings.Count(ofx.String(), "<NAME>Holiday</NAME>"))
+	IsEqual(t, "ofx ledger balance", true, strings.Contains(ofx.String(), "<BALAMT>47.66</BALAMT>"))
+
+	var qif bytes.Buffer
+	_, err = export.Export(export.NewPages(client, account.ID, time.Time{}, time.Time{}), export.NewQIFWriter(&qif, statement))
+	IsEqual(t, "qif error", nil, err)
+	IsEqual(t, "qif deposit", true, strings.Contains(qif.String(), "T-50.00\nPHoliday\nL[Holiday]\n^\n"))
+	IsEqual(t, "qif withdrawal", true, strings.Contains(qif.String(), "T10.00\nPHoliday\nL[Holiday]\n^\n"))
+}
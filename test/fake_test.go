@@ -0,0 +1,87 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+// sweep is the kind of business logic the fake exists for: move everything
+// above a buffer from the current account into a pot.
+func sweep(api monzo.API, potID string, buffer int64) (model.Pot, error) {
+	account, err := api.CurrentAccount()
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	balance, err := api.Balance(account.ID)
+	if err != nil {
+		return model.Pot{}, err
+	}
+
+	if balance.Balance <= buffer {
+		return model.Pot{}, nil
+	}
+
+	return api.Deposit(potID, account.ID, balance.Balance-buffer)
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	fake := monzotest.NewFake()
+	fake.CurrentAccountFunc = func() (model.Account, error) {
+		return model.Account{ID: "x-account-id"}, nil
+	}
+	fake.BalanceFunc = func(accountID string) (model.Balance, error) {
+		return model.Balance{Balance: 15000, Currency: "GBP"}, nil
+	}
+	fake.DepositFunc = func(targetPotID string, sourceAccountID string, amount int64) (model.Pot, error) {
+		return model.Pot{ID: targetPotID, Balance: amount, Currency: "GBP"}, nil
+	}
+
+	pot, err := sweep(fake, "x-pot-id", 10000)
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "pot.balance", int64(5000), pot.Balance)
+
+	IsEqual(t, "count(calls)", 3, len(fake.Calls()))
+
+	deposits := fake.CallsTo("Deposit")
+	IsEqual(t, "count(deposits)", 1, len(deposits))
+	IsEqual(t, "deposit args", []interface{}{"x-pot-id", "x-account-id", int64(5000)}, deposits[0].Args)
+
+	fake.Reset()
+	IsEqual(t, "count(calls) after reset", 0, len(fake.Calls()))
+}
+
+func TestFakeProgrammedError(t *testing.T) {
+	failure := errors.New("x-failure")
+
+	fake := monzotest.NewFake()
+	fake.CurrentAccountFunc = func() (model.Account, error) {
+		return model.Account{}, failure
+	}
+
+	_, err := sweep(fake, "x-pot-id", 10000)
+	IsEqual(t, "error", failure, err)
+	IsEqual(t, "count(balance calls)", 0, len(fake.CallsTo("Balance")))
+}
+
+func TestFakeWithNotifier(t *testing.T) {
+	fake := monzotest.NewFake()
+
+	notifier, err := monzo.NewNotifier(fake, monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}} is full`,
+		ImageURL:      "https://example.com/pot.png",
+	})
+	IsEqual(t, "error", nil, err)
+
+	notifier.Notify("x-account-id", model.Pot{Name: "Holiday"})
+
+	posts := fake.CallsTo("PostFeedItem")
+	IsEqual(t, "count(posts)", 1, len(posts))
+
+	item := posts[0].Args[1].(*monzo.FeedItem)
+	IsEqual(t, "title", "Holiday is full", item.Title())
+}
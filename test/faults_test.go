@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+func TestFaultServerErrorThenRecover(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+
+	faults := monzotest.NewFaultTransport(http.DefaultTransport).
+		Inject(http.MethodGet, "/balance", monzotest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+
+	defer useTransport(faults)()
+
+	_, err := client.Balance(account.ID)
+	IsEqual(t, "first error", true, err != nil && strings.Contains(err.Error(), "internal_service_error"))
+
+	balance, err := client.Balance(account.ID)
+	IsEqual(t, "second error", nil, err)
+	IsEqual(t, "balance", int64(10000), balance.Balance)
+	IsEqual(t, "injected", 1, faults.Injected())
+}
+
+func TestFaultRateLimited(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	faults := monzotest.NewFaultTransport(nil).Inject("", "/accounts", monzotest.RateLimited(1500*time.Millisecond))
+	client := &http.Client{Transport: faults}
+
+	response, err := client.Get(server.URL + "/accounts")
+	IsEqual(t, "error", nil, err)
+	response.Body.Close()
+
+	IsEqual(t, "status", http.StatusTooManyRequests, response.StatusCode)
+	IsEqual(t, "retry after", "2", response.Header.Get("Retry-After"))
+}
+
+func TestFaultTruncatedBody(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	server.AddAccount("Current", 10000, "GBP")
+
+	faults := monzotest.NewFaultTransport(http.DefaultTransport).Inject("", "/accounts", monzotest.TruncatedBody())
+
+	defer useTransport(faults)()
+
+	_, err := client.Accounts()
+	IsEqual(t, "truncated error", true, err != nil)
+}
+
+func TestFaultConnectionResetAndLatency(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	faults := monzotest.NewFaultTransport(nil).
+		Inject("", "/accounts", monzotest.ConnectionReset()).
+		Inject("", "/pots", monzotest.Latency(time.Second))
+	client := &http.Client{Transport: faults}
+
+	_, err := client.Get(server.URL + "/accounts")
+	IsEqual(t, "reset", true, errors.Is(err, syscall.ECONNRESET))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/pots", nil)
+
+	_, err = client.Do(request)
+	IsEqual(t, "latency", true, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestFaultResponseLostIsDeduped(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	user := server.IssueToken()
+
+	faults := monzotest.NewFaultTransport(nil).
+		Inject(http.MethodPut, "/pots/*/deposit", monzotest.Fault{LoseResponse: true, Times: 1})
+	client := monzo.New(user.TokenType, user.AccessToken, monzo.WithHTTPClient(&http.Client{Transport: faults}))
+
+	dedupeID := monzo.NewDedupeID()
+
+	_, err := client.DepositWithDedupe(pot.ID, account.ID, 1000, dedupeID)
+	IsEqual(t, "lost", true, errors.Is(err, monzotest.ErrResponseLost))
+
+	deposited, err := client.DepositWithDedupe(pot.ID, account.ID, 1000, dedupeID)
+	IsEqual(t, "retry error", nil, err)
+	IsEqual(t, "retry balance", int64(1000), deposited.Balance)
+
+	pots, _ := client.AccountPots(account.ID)
+	IsEqual(t, "pot balance", int64(1000), pots[0].Balance)
+
+	balance, _ := client.Balance(account.ID)
+	IsEqual(t, "account balance", int64(9000), balance.Balance)
+}
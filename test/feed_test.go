@@ -0,0 +1,135 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestFeedItemCreateSuccess(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Test Headers
+		IsEqual(t, "Method", http.MethodPost, r.Method)
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+		IsEqual(t, "Content-Type", "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+		// Test Form
+		IsEqual(t, "account_id", "x-account-id", r.PostFormValue("account_id"))
+		IsEqual(t, "type", "basic", r.PostFormValue("type"))
+		IsEqual(t, "url", "https://dashboard.example.com/alerts/1", r.PostFormValue("url"))
+		IsEqual(t, "params[title]", "Budget alert", r.PostFormValue("params[title]"))
+		IsEqual(t, "params[body]", "You have spent 90% of your budget", r.PostFormValue("params[body]"))
+		IsEqual(t, "params[image_url]", "https://example.com/alert.png", r.PostFormValue("params[image_url]"))
+		IsEqual(t, "params[background_color]", "#FCF1EE", r.PostFormValue("params[background_color]"))
+		IsEqual(t, "params[title_color]", "#333333", r.PostFormValue("params[title_color]"))
+		IsEqual(t, "params[body_color]", "#FE527A", r.PostFormValue("params[body_color]"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	item := monzo.NewFeedItem("Budget alert", "https://example.com/alert.png").
+		WithBody("You have spent 90% of your budget").
+		WithURL("https://dashboard.example.com/alerts/1").
+		WithBackgroundColor("#FCF1EE").
+		WithTitleColor("#333333").
+		WithBodyColor("#FE527A")
+
+	err := monzo.New("Bearer", "x-access-token").PostFeedItem("x-account-id", item)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+}
+
+func TestFeedItemOptionalParamsOmitted(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		_, hasURL := r.PostForm["url"]
+		_, hasBackground := r.PostForm["params[background_color]"]
+
+		IsEqual(t, "has url", false, hasURL)
+		IsEqual(t, "has params[background_color]", false, hasBackground)
+		IsEqual(t, "params[body]", "x-body", r.PostFormValue("params[body]"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	err := monzo.New("Bearer", "x-access-token").CreateFeedItem("x-account-id", "x-title", "x-body", "https://example.com/x.png")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+}
+
+func TestFeedItemValidationFail(t *testing.T) {
+	items := map[string]*monzo.FeedItem{
+		"title":            monzo.NewFeedItem("", "https://example.com/x.png"),
+		"image url":        monzo.NewFeedItem("x-title", ""),
+		"url":              monzo.NewFeedItem("x-title", "https://example.com/x.png").WithURL("/relative"),
+		"background color": monzo.NewFeedItem("x-title", "https://example.com/x.png").WithBackgroundColor("red"),
+		"title color":      monzo.NewFeedItem("x-title", "https://example.com/x.png").WithTitleColor("#FFF"),
+		"body color":       monzo.NewFeedItem("x-title", "https://example.com/x.png").WithBodyColor("FFFFFF"),
+	}
+
+	for name, item := range items {
+		err := item.Validate()
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Logf("expected %s validation error, got %v", name, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestFeedItemValidationOrder(t *testing.T) {
+	item := monzo.NewFeedItem("x-title", "https://example.com/x.png").
+		WithBodyColor("x-body").
+		WithTitleColor("x-title").
+		WithBackgroundColor("x-background")
+
+	for i := 0; i < 20; i++ {
+		err := item.Validate()
+		if err == nil || !strings.Contains(err.Error(), "background color") {
+			t.Fatalf("expected background color error first, got %v", err)
+		}
+	}
+}
+
+func TestFeedItemAPIErrorFail(t *testing.T) {
+	sampleFeedFail := `
+{
+	"error": "bad_request.missing_param.params[image_url]"
+}
+`
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(sampleFeedFail))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	item := monzo.NewFeedItem("x-title", "https://example.com/x.png")
+
+	err := monzo.New("Bearer", "x-access-token").PostFeedItem("x-account-id", item)
+	if err == nil || !strings.Contains(err.Error(), "bad_request.missing_param") {
+		t.Log(err)
+		t.Fail()
+	}
+}
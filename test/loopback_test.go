@@ -0,0 +1,122 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestLoopbackLogin(t *testing.T) {
+	var redirectURI string
+
+	oauthHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "code", "x-code", r.PostFormValue("code"))
+		IsEqual(t, "redirect_uri", redirectURI, r.PostFormValue("redirect_uri"))
+
+		response, _ := json.Marshal(map[string]interface{}{
+			"user_id":       "x-user-id",
+			"access_token":  "x-access-token",
+			"refresh_token": "x-refresh-token",
+			"token_type":    "Bearer",
+			"expires_in":    21600,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+
+	defer oauthHttp.Close()
+
+	approved := false
+	accountsHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if !approved {
+			approved = true
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(sampleInsufficientPermissions))
+			return
+		}
+
+		w.Write([]byte(`{"accounts": []}`))
+	}))
+
+	defer accountsHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, oauthHttp.URL)
+	monzo.SetURL(monzo.AccountsURL, accountsHttp.URL)
+
+	var output bytes.Buffer
+	config := monzo.LoopbackConfig{
+		Output:       &output,
+		PollInterval: time.Millisecond,
+		Open: func(authURL string) error {
+			parsed, err := url.Parse(authURL)
+			if err != nil {
+				return err
+			}
+
+			redirectURI = parsed.Query().Get("redirect_uri")
+			IsEqual(t, "redirect_uri is loopback", true, strings.HasPrefix(redirectURI, "http://127.0.0.1:"))
+
+			callback := url.Values{}
+			callback.Set("code", "x-code")
+			callback.Set("state", parsed.Query().Get("state"))
+
+			response, err := http.Get(redirectURI + "?" + callback.Encode())
+			if err != nil {
+				return err
+			}
+
+			defer response.Body.Close()
+
+			IsEqual(t, "callback status", http.StatusOK, response.StatusCode)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := monzo.LoopbackLogin(ctx, config)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "user.access_token", "x-access-token", user.AccessToken)
+	IsEqual(t, "approved", true, approved)
+	IsEqual(t, "output", true, strings.Contains(output.String(), "Approve access"))
+}
+
+func TestLoopbackLoginForgedStateFail(t *testing.T) {
+	config := monzo.LoopbackConfig{
+		Output: &bytes.Buffer{},
+		Open: func(authURL string) error {
+			parsed, _ := url.Parse(authURL)
+
+			response, err := http.Get(parsed.Query().Get("redirect_uri") + "?code=x-code&state=x-forged-state")
+			if err != nil {
+				return err
+			}
+
+			response.Body.Close()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := monzo.LoopbackLogin(ctx, config)
+	IsEqual(t, "error", monzo.ErrInvalidState, err)
+}
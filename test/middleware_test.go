@@ -0,0 +1,81 @@
+package test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestMiddlewareOrderAndHeaders(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	user := server.IssueToken()
+
+	var order []string
+	trace := func(name string) monzo.Middleware {
+		return func(next monzo.Doer) monzo.Doer {
+			return func(call *monzo.Call) (*http.Response, error) {
+				order = append(order, name+":"+call.Operation)
+				call.Request.Header.Set("X-Trace-Id", "x-trace-id")
+
+				return next(call)
+			}
+		}
+	}
+
+	var traced string
+	inspect := func(next monzo.Doer) monzo.Doer {
+		return func(call *monzo.Call) (*http.Response, error) {
+			traced = call.Request.Header.Get("X-Trace-Id")
+			return next(call)
+		}
+	}
+
+	client := monzo.New(user.TokenType, user.AccessToken, monzo.WithMiddleware(trace("outer"), trace("inner")), monzo.WithMiddleware(inspect))
+
+	_, err := client.Deposit(pot.ID, account.ID, 100)
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "order", []string{"outer:pots.deposit", "inner:pots.deposit"}, order)
+	IsEqual(t, "traced", "x-trace-id", traced)
+}
+
+func TestMiddlewareLoggingAndTiming(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	user := server.IssueToken()
+
+	var output bytes.Buffer
+	logger := log.New(&output, "", 0)
+
+	var statuses []int
+	timing := monzo.Timing(func(operation string, status int, duration time.Duration, err error) {
+		statuses = append(statuses, status)
+	})
+
+	client := monzo.New(user.TokenType, user.AccessToken, monzo.WithMiddleware(monzo.Logging(logger), timing))
+
+	_, err := client.Balance(account.ID)
+	IsEqual(t, "balance error", nil, err)
+
+	_, err = client.Balance("x-missing-account")
+	IsEqual(t, "missing error", true, err != nil)
+
+	IsEqual(t, "statuses", []int{http.StatusOK, http.StatusNotFound}, statuses)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	IsEqual(t, "count(lines)", 2, len(lines))
+	IsEqual(t, "line", true, strings.HasPrefix(lines[0], "monzo balance.read GET /balance 200 "))
+	IsEqual(t, "no query", false, strings.Contains(output.String(), account.ID))
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestMoneyFormat(t *testing.T) {
+	formats := map[string]model.Money{
+		"£12.34":     model.NewMoney(1234, "GBP"),
+		"-€5.00":     model.NewMoney(-500, "EUR"),
+		"£0.05":      model.NewMoney(5, "GBP"),
+		"¥500":       model.NewMoney(500, "JPY"),
+		"1.234 KWD":  model.NewMoney(1234, "KWD"),
+		"-0.01 XYZ":  model.NewMoney(-1, "xyz"),
+		"$1000.00":   model.NewMoney(100000, "USD"),
+		"-12.30 CHF": model.NewMoney(-1230, "CHF"),
+	}
+
+	for expected, money := range formats {
+		IsEqual(t, expected, expected, money.String())
+	}
+
+	IsEqual(t, "major", "-92233720368547758.08", model.NewMoney(math.MinInt64, "GBP").Major())
+}
+
+func TestMoneyParse(t *testing.T) {
+	inputs := map[string]model.Money{
+		"£12.34":       model.NewMoney(1234, "GBP"),
+		"12.3":         model.NewMoney(1230, "GBP"),
+		"-5":           model.NewMoney(-500, "GBP"),
+		"-€5.00":       model.NewMoney(-500, "EUR"),
+		"€-5":          model.NewMoney(-500, "EUR"),
+		"1,250.50 EUR": model.NewMoney(125050, "EUR"),
+		"JPY 500":      model.NewMoney(500, "JPY"),
+		"usd .5":       model.NewMoney(50, "USD"),
+		"£ 1,000":      model.NewMoney(100000, "GBP"),
+	}
+
+	for input, expected := range inputs {
+		actual, err := model.ParseMoney(input, "GBP")
+		IsEqual(t, input+" error", nil, err)
+		IsEqual(t, input, expected, actual)
+	}
+
+	invalid := []string{"", "£", "12.345", "abc", "JPY 5.5", "1.2.3", "99999999999999999999"}
+	for _, input := range invalid {
+		if _, err := model.ParseMoney(input, "GBP"); err == nil {
+			t.Logf("expected error parsing %q", input)
+			t.Fail()
+		}
+	}
+
+	ambiguous := map[string]string{"¥500": "GBP", "kr 100": "EUR", "¥12": ""}
+	for input, defaultCurrency := range ambiguous {
+		if _, err := model.ParseMoney(input, defaultCurrency); err == nil {
+			t.Logf("expected error parsing %q with default %q", input, defaultCurrency)
+			t.Fail()
+		}
+	}
+
+	settled := map[string]model.Money{"JPY": model.NewMoney(500, "JPY"), "CNY": model.NewMoney(50000, "CNY")}
+	for defaultCurrency, expected := range settled {
+		actual, err := model.ParseMoney("¥500", defaultCurrency)
+		IsEqual(t, "¥500 "+defaultCurrency+" error", nil, err)
+		IsEqual(t, "¥500 "+defaultCurrency, expected, actual)
+	}
+
+	if _, err := model.ParseMoney("12", ""); err == nil {
+		t.Log("expected error parsing without a currency")
+		t.Fail()
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := model.NewMoney(1000, "GBP").Add(model.NewMoney(250, "gbp"))
+	IsEqual(t, "add error", nil, err)
+	IsEqual(t, "sum", model.NewMoney(1250, "GBP"), sum)
+
+	difference, err := model.NewMoney(1000, "GBP").Sub(model.NewMoney(2500, "GBP"))
+	IsEqual(t, "sub error", nil, err)
+	IsEqual(t, "difference", model.NewMoney(-1500, "GBP"), difference)
+	IsEqual(t, "negative", true, difference.IsNegative())
+
+	_, err = model.NewMoney(1000, "GBP").Add(model.NewMoney(1000, "EUR"))
+	IsEqual(t, "mixed error", model.ErrCurrencyMismatch, err)
+
+	_, err = model.NewMoney(math.MaxInt64, "GBP").Add(model.NewMoney(1, "GBP"))
+	IsEqual(t, "overflow error", model.ErrAmountOverflow, err)
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	pot := model.Pot{ID: "x-pot-id", Name: "Holiday", Balance: 350000, Currency: "GBP"}
+
+	response, err := json.Marshal(pot.Money())
+	IsEqual(t, "marshal error", nil, err)
+	IsEqual(t, "json", `{"amount":350000,"currency":"GBP"}`, string(response))
+
+	var money model.Money
+	IsEqual(t, "unmarshal error", nil, json.Unmarshal(response, &money))
+	IsEqual(t, "money", pot.Money(), money)
+
+	balance := model.Balance{Balance: 12000, TotalBalance: 22800, SpendToday: -500, Currency: "GBP"}
+	IsEqual(t, "balance", "£120.00", balance.Money().String())
+	IsEqual(t, "total balance", "£228.00", balance.TotalMoney().String())
+	IsEqual(t, "spend today", "-£5.00", balance.SpendTodayMoney().String())
+
+	var transaction model.Transaction
+	IsEqual(t, "transaction error", nil, json.Unmarshal([]byte(`{"data": {"amount": -350, "currency": "EUR"}}`), &transaction))
+	IsEqual(t, "transaction", "-€3.50", transaction.Money().String())
+}
+
+func TestPotDepositMoney(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Amount", "1250", r.PostFormValue("amount"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "x-pot-id", "balance": 1250, "currency": "GBP"}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.DepositURL, testHttp.URL)
+
+	amount, _ := model.ParseMoney("£12.50", "GBP")
+
+	target := model.Pot{ID: "x-pot-id", Currency: "GBP"}
+
+	pot, err := monzo.New("Bearer", "x-access-token").DepositMoney(target, "x-account-id", amount)
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "pot.balance", amount, pot.Money())
+
+	_, err = monzo.New("Bearer", "x-access-token").DepositMoney(target, "x-account-id", amount.Neg())
+	IsEqual(t, "negative error", true, err != nil)
+}
+
+func TestPotTransferMoneyCurrencyMismatchFail(t *testing.T) {
+	requests := 0
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.DepositURL, testHttp.URL)
+	monzo.SetURL(monzo.WithdrawURL, testHttp.URL)
+
+	pot := model.Pot{ID: "x-pot-id", Currency: "GBP"}
+	amount, _ := model.ParseMoney("500 JPY", "GBP")
+
+	_, err := monzo.New("Bearer", "x-access-token").DepositMoney(pot, "x-account-id", amount)
+	IsEqual(t, "deposit error", "transfer amount is in JPY but pot x-pot-id holds GBP", err.Error())
+
+	_, err = monzo.New("Bearer", "x-access-token").WithdrawMoney(pot, "x-account-id", amount)
+	IsEqual(t, "withdraw error", "transfer amount is in JPY but pot x-pot-id holds GBP", err.Error())
+	IsEqual(t, "requests", 0, requests)
+}
@@ -10,6 +10,7 @@ import (
 
 	"encoding/json"
 
+	"reflect"
 	"strings"
 
 	"github.com/gurparit/go-monzo/model"
@@ -323,7 +324,7 @@ func TestMonzoAccountsUpdate(t *testing.T) {
 }
 
 func IsEqual(t *testing.T, key string, expected interface{}, actual interface{}) {
-	if expected != actual {
+	if !reflect.DeepEqual(expected, actual) {
 		t.Logf("for %s;", key)
 		t.Logf("expected %s;", expected)
 		t.Logf("actual: %s;", actual)
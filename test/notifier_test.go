@@ -0,0 +1,248 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+type feedRecorder struct {
+	mu     sync.Mutex
+	titles []string
+	bodies []string
+}
+
+func (f *feedRecorder) server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Method", http.MethodPost, r.Method)
+		IsEqual(t, "account_id", "x-account-id", r.PostFormValue("account_id"))
+
+		f.mu.Lock()
+		f.titles = append(f.titles, r.PostFormValue("params[title]"))
+		f.bodies = append(f.bodies, r.PostFormValue("params[body]"))
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+}
+
+func (f *feedRecorder) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.titles)
+}
+
+func TestNotifierRendersTemplate(t *testing.T) {
+	recorder := &feedRecorder{}
+	testHttp := recorder.server(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}} reached £{{amount .Balance .Currency}}`,
+		BodyTemplate:  `Currency: {{.Currency}}`,
+		ImageURL:      "https://example.com/pot.png",
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	pot := model.Pot{ID: "x-pot-id", Name: "Holiday", Balance: 123456, Currency: "GBP"}
+	if err := notifier.Notify("x-account-id", pot); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "count(items)", 1, recorder.count())
+	IsEqual(t, "title", "Holiday reached £1234.56", recorder.titles[0])
+	IsEqual(t, "body", "Currency: GBP", recorder.bodies[0])
+}
+
+func TestNotifierCoalescesBurst(t *testing.T) {
+	recorder := &feedRecorder{}
+	testHttp := recorder.server(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}}`,
+		ImageURL:      "https://example.com/pot.png",
+		Window:        time.Hour,
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	for _, name := range []string{"Holiday", "Rainy Day", "Car"} {
+		if err := notifier.Notify("x-account-id", model.Pot{Name: name}); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+
+	IsEqual(t, "count(items) before flush", 0, recorder.count())
+
+	if err := notifier.Flush(); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "count(items)", 1, recorder.count())
+	IsEqual(t, "title", "3 new notifications", recorder.titles[0])
+	IsEqual(t, "body", "Holiday, Rainy Day, Car", recorder.bodies[0])
+}
+
+func TestNotifierWindowElapses(t *testing.T) {
+	recorder := &feedRecorder{}
+	testHttp := recorder.server(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}}`,
+		ImageURL:      "https://example.com/pot.png",
+		Window:        20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	notifier.Notify("x-account-id", model.Pot{Name: "Holiday"})
+
+	deadline := time.Now().Add(time.Second)
+	for recorder.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	IsEqual(t, "count(items)", 1, recorder.count())
+	IsEqual(t, "title", "Holiday", recorder.titles[0])
+}
+
+func TestNotifierRateLimitFail(t *testing.T) {
+	recorder := &feedRecorder{}
+	testHttp := recorder.server(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}}`,
+		ImageURL:      "https://example.com/pot.png",
+		MaxPerHour:    2,
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "first", nil, notifier.Notify("x-account-id", model.Pot{Name: "1"}))
+	IsEqual(t, "second", nil, notifier.Notify("x-account-id", model.Pot{Name: "2"}))
+	IsEqual(t, "third", monzo.ErrRateLimited, notifier.Notify("x-account-id", model.Pot{Name: "3"}))
+	IsEqual(t, "count(items)", 2, recorder.count())
+}
+
+func TestNotifierAmountUsesCurrency(t *testing.T) {
+	recorder := &feedRecorder{}
+	testHttp := recorder.server(t)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, _ := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{amount .Balance .Currency}} {{.Currency}}`,
+		ImageURL:      "https://example.com/pot.png",
+	})
+
+	notifier.Notify("x-account-id", model.Pot{Balance: 5000, Currency: "JPY"})
+	notifier.Notify("x-account-id", model.Pot{Balance: 12345, Currency: "BHD"})
+
+	IsEqual(t, "titles", []string{"5000 JPY", "12.345 BHD"}, recorder.titles)
+}
+
+func TestNotifierFailedSendKeepsSlot(t *testing.T) {
+	failures := 1
+	recorder := &feedRecorder{}
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code": "internal_error"}`))
+			return
+		}
+
+		recorder.titles = append(recorder.titles, r.PostFormValue("params[title]"))
+		w.Write([]byte("{}"))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.FeedItemCreateURL, testHttp.URL)
+
+	notifier, _ := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}}`,
+		ImageURL:      "https://example.com/pot.png",
+		MaxPerHour:    1,
+	})
+
+	IsEqual(t, "failed", true, notifier.Notify("x-account-id", model.Pot{Name: "1"}) != nil)
+	IsEqual(t, "retry", nil, notifier.Notify("x-account-id", model.Pot{Name: "1"}))
+	IsEqual(t, "limited", monzo.ErrRateLimited, notifier.Notify("x-account-id", model.Pot{Name: "2"}))
+	IsEqual(t, "titles", []string{"1"}, recorder.titles)
+}
+
+func TestNotifierEmptyTitleFail(t *testing.T) {
+	_, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		BodyTemplate: `{{.Name}}`,
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNotifierInvalidTemplateFail(t *testing.T) {
+	_, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name`,
+		ImageURL:      "https://example.com/pot.png",
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNotifierFeedItemURLsFail(t *testing.T) {
+	configs := map[string]monzo.NotifierConfig{
+		"missing image":  {TitleTemplate: `{{.Name}}`},
+		"relative image": {TitleTemplate: `{{.Name}}`, ImageURL: "pot.png"},
+		"invalid url":    {TitleTemplate: `{{.Name}}`, ImageURL: "https://example.com/pot.png", URL: "example.com/pots"},
+	}
+
+	for name, config := range configs {
+		_, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), config)
+		IsEqual(t, name, true, err != nil)
+	}
+
+	_, err := monzo.NewNotifier(monzo.New("Bearer", "x-access-token"), monzo.NotifierConfig{
+		TitleTemplate: `{{.Name}}`,
+		ImageURL:      "https://example.com/pot.png",
+		URL:           "https://example.com/pots",
+	})
+	IsEqual(t, "valid", nil, err)
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+	"github.com/gurparit/go-monzo/otelmonzo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func otelProviders() (*tracetest.SpanRecorder, *sdktrace.TracerProvider, *sdkmetric.ManualReader, *sdkmetric.MeterProvider) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	return spans, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		reader, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+}
+
+func spanAttribute(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func counterTotal(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	var data metricdata.ResourceMetrics
+	err := reader.Collect(context.Background(), &data)
+	IsEqual(t, "collect error", nil, err)
+
+	var total int64
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == name {
+				for _, point := range sum.DataPoints {
+					total += point.Value
+				}
+			}
+		}
+	}
+
+	return total
+}
+
+func TestOtelSpansAndMetrics(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	spans, tracerProvider, reader, meterProvider := otelProviders()
+
+	instrumentation, err := otelmonzo.New(otelmonzo.Config{TracerProvider: tracerProvider, MeterProvider: meterProvider})
+	IsEqual(t, "new error", nil, err)
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	user := server.IssueToken()
+
+	client := monzo.New(user.TokenType, user.AccessToken, instrumentation.Option())
+
+	_, err = client.Deposit(pot.ID, account.ID, 100)
+	IsEqual(t, "deposit error", nil, err)
+
+	_, err = client.Balance("x-missing-account")
+	IsEqual(t, "balance error", true, err != nil)
+
+	ended := spans.Ended()
+	IsEqual(t, "count(spans)", 2, len(ended))
+	IsEqual(t, "span name", "pots.deposit", ended[0].Name())
+
+	hash, ok := spanAttribute(ended[0].Attributes(), "monzo.account_id_hash")
+	IsEqual(t, "account hash set", true, ok)
+	IsEqual(t, "account hash hides id", false, hash.AsString() == account.ID)
+
+	status, _ := spanAttribute(ended[0].Attributes(), "http.response.status_code")
+	IsEqual(t, "status", int64(http.StatusOK), status.AsInt64())
+
+	requestID, _ := spanAttribute(ended[0].Attributes(), "monzo.request_id")
+	IsEqual(t, "request id", true, requestID.AsString() != "")
+
+	IsEqual(t, "error status", codes.Error, ended[1].Status().Code)
+
+	IsEqual(t, "requests", int64(2), counterTotal(t, reader, "monzo.client.requests"))
+}
+
+func TestOtelRefreshesAndRateLimits(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	_, tracerProvider, reader, meterProvider := otelProviders()
+
+	instrumentation, err := otelmonzo.New(otelmonzo.Config{TracerProvider: tracerProvider, MeterProvider: meterProvider})
+	IsEqual(t, "new error", nil, err)
+
+	expiring := server.IssueToken()
+	expiring.ExpiryDate = time.Now().Add(10 * time.Second)
+
+	source := monzo.NewTokenSource(monzo.NewMemoryTokenStore(expiring))
+	source.Options = []monzo.Option{instrumentation.Option()}
+
+	_, err = source.Token()
+	IsEqual(t, "refresh error", nil, err)
+
+	faults := monzotest.NewFaultTransport(http.DefaultTransport).Inject("", "/accounts", monzotest.RateLimited(0))
+
+	defer useTransport(faults)()
+
+	user := server.IssueToken()
+	_, err = monzo.New(user.TokenType, user.AccessToken, instrumentation.Option()).Accounts()
+	IsEqual(t, "rate limited", true, err != nil)
+
+	IsEqual(t, "refreshes", int64(1), counterTotal(t, reader, "monzo.client.token.refreshes"))
+	IsEqual(t, "rate limits", int64(1), counterTotal(t, reader, "monzo.client.rate_limits"))
+}
+
+func TestOtelRetryCount(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	spans, tracerProvider, _, meterProvider := otelProviders()
+
+	instrumentation, _ := otelmonzo.New(otelmonzo.Config{TracerProvider: tracerProvider, MeterProvider: meterProvider})
+
+	retry := func(next monzo.Doer) monzo.Doer {
+		return func(call *monzo.Call) (*http.Response, error) {
+			call.Request = call.Request.WithContext(otelmonzo.WithRetryCount(call.Request.Context(), 2))
+			return next(call)
+		}
+	}
+
+	user := server.IssueToken()
+	monzo.New(user.TokenType, user.AccessToken, monzo.WithMiddleware(retry), instrumentation.Option()).Accounts()
+
+	count, _ := spanAttribute(spans.Ended()[0].Attributes(), "monzo.retry_count")
+	IsEqual(t, "retry count", int64(2), count.AsInt64())
+}
+
+func TestOtelSpanJoinsCallerTrace(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	spans, tracerProvider, _, meterProvider := otelProviders()
+
+	instrumentation, _ := otelmonzo.New(otelmonzo.Config{TracerProvider: tracerProvider, MeterProvider: meterProvider})
+
+	server.AddAccount("Current", 10000, "GBP")
+	user := server.IssueToken()
+
+	client := monzo.New(user.TokenType, user.AccessToken, instrumentation.Option())
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "dashboard")
+	_, err := client.Snapshot(ctx)
+	IsEqual(t, "snapshot error", nil, err)
+	parent.End()
+
+	ended := spans.Ended()
+	IsEqual(t, "count(spans)", 4, len(ended))
+
+	for _, span := range ended[:3] {
+		IsEqual(t, span.Name()+" trace", parent.SpanContext().TraceID(), span.SpanContext().TraceID())
+		IsEqual(t, span.Name()+" parent", parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
@@ -0,0 +1,207 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+	"github.com/gurparit/go-monzo/prommonzo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExporterRefresh(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 12345, "GBP")
+	holiday := server.AddPot(account.ID, "Holiday", 5000)
+	deleted := server.AddPot(account.ID, "Old", 100)
+	server.DeletePot(deleted.ID)
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -345})
+
+	store := monzo.NewMemoryTokenStore(server.IssueToken())
+	user, _ := store.Load()
+	user.UpdateExpiry()
+	store.Save(user)
+
+	registry := prometheus.NewRegistry()
+	exporter, err := prommonzo.NewExporter(prommonzo.TokenSourceClient(monzo.NewTokenSource(store)), registry)
+	IsEqual(t, "new error", nil, err)
+
+	err = exporter.Refresh()
+	IsEqual(t, "refresh error", nil, err)
+
+	expected := `
+# HELP monzo_account_balance Available balance of the account in major currency units.
+# TYPE monzo_account_balance gauge
+monzo_account_balance{account="` + account.ID + `",currency="GBP"} 120
+# HELP monzo_account_spend_today Amount spent from the account today in major currency units, negative for spend.
+# TYPE monzo_account_spend_today gauge
+monzo_account_spend_today{account="` + account.ID + `",currency="GBP"} -3.45
+# HELP monzo_account_total_balance Balance of the account including pots in major currency units.
+# TYPE monzo_account_total_balance gauge
+monzo_account_total_balance{account="` + account.ID + `",currency="GBP"} 170
+# HELP monzo_pot_balance Balance of the pot in major currency units.
+# TYPE monzo_pot_balance gauge
+monzo_pot_balance{account="` + account.ID + `",currency="GBP",pot="Holiday",pot_id="` + holiday.ID + `"} 50
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"monzo_account_balance", "monzo_account_spend_today", "monzo_account_total_balance", "monzo_pot_balance")
+	IsEqual(t, "metrics", nil, err)
+}
+
+func TestExporterScrapeErrors(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	server.AddAccount("Current", 10000, "GBP")
+	user := server.IssueToken()
+
+	faults := monzotest.NewFaultTransport(http.DefaultTransport).Inject("", "/pots", monzotest.ServerError(http.StatusInternalServerError))
+
+	defer useTransport(faults)()
+
+	registry := prometheus.NewRegistry()
+	exporter, _ := prommonzo.NewExporter(func() (monzo.API, error) {
+		return monzo.New(user.TokenType, user.AccessToken), nil
+	}, registry)
+
+	var stages []string
+	exporter.OnError = func(stage string, err error) {
+		stages = append(stages, stage)
+	}
+
+	err := exporter.Refresh()
+	IsEqual(t, "pots error", true, err != nil)
+
+	exporter.Client = func() (monzo.API, error) {
+		return nil, errors.New("x-token-failure")
+	}
+
+	err = exporter.Refresh()
+	IsEqual(t, "token error", true, err != nil)
+
+	IsEqual(t, "stages", []string{prommonzo.StagePots, prommonzo.StageToken}, stages)
+
+	expected := `
+# HELP monzo_scrape_errors_total Errors while refreshing metrics from Monzo, by stage.
+# TYPE monzo_scrape_errors_total counter
+monzo_scrape_errors_total{stage="accounts"} 0
+monzo_scrape_errors_total{stage="balance"} 0
+monzo_scrape_errors_total{stage="pots"} 1
+monzo_scrape_errors_total{stage="token"} 1
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "monzo_scrape_errors_total")
+	IsEqual(t, "metrics", nil, err)
+}
+
+func TestFileTokenStore(t *testing.T) {
+	store := monzo.NewFileTokenStore(t.TempDir() + "/tokens/monzo.json")
+
+	_, err := store.Load()
+	IsEqual(t, "empty", monzo.ErrNoToken, err)
+
+	user := model.User{AccessToken: "x-access-token", RefreshToken: "x-refresh-token", ExpiryDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	err = store.Save(user)
+	IsEqual(t, "save error", nil, err)
+
+	loaded, err := store.Load()
+	IsEqual(t, "load error", nil, err)
+	IsEqual(t, "user", user, loaded)
+
+	err = store.Delete()
+	IsEqual(t, "delete error", nil, err)
+
+	_, err = store.Load()
+	IsEqual(t, "deleted", monzo.ErrNoToken, err)
+}
+
+func TestExporterPotsPerAccount(t *testing.T) {
+	fake := &monzotest.Fake{
+		AccountsFunc: func() (model.Monzo, error) {
+			return model.Monzo{Accounts: []model.Account{{ID: "acc_1"}, {ID: "acc_2"}}}, nil
+		},
+		BalanceFunc: func(accountID string) (model.Balance, error) {
+			return model.Balance{Balance: 100, Currency: "GBP"}, nil
+		},
+		AccountPotsFunc: func(accountID string) ([]model.Pot, error) {
+			if accountID == "acc_2" {
+				return nil, errors.New("x-pots-failure")
+			}
+
+			return []model.Pot{{ID: "pot_1", Name: "Holiday", Balance: 2500, Currency: "GBP"}}, nil
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	exporter, _ := prommonzo.NewExporter(func() (monzo.API, error) {
+		return fake, nil
+	}, registry)
+
+	err := exporter.Refresh()
+	IsEqual(t, "pots error", true, err != nil)
+
+	IsEqual(t, "pots calls", 2, len(fake.CallsTo("AccountPots")))
+	IsEqual(t, "unscoped pots calls", 0, len(fake.CallsTo("Pots")))
+
+	expected := `
+# HELP monzo_pot_balance Balance of the pot in major currency units.
+# TYPE monzo_pot_balance gauge
+monzo_pot_balance{account="acc_1",currency="GBP",pot="Holiday",pot_id="pot_1"} 25
+# HELP monzo_scrape_errors_total Errors while refreshing metrics from Monzo, by stage.
+# TYPE monzo_scrape_errors_total counter
+monzo_scrape_errors_total{stage="accounts"} 0
+monzo_scrape_errors_total{stage="balance"} 0
+monzo_scrape_errors_total{stage="pots"} 1
+monzo_scrape_errors_total{stage="token"} 0
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "monzo_pot_balance", "monzo_scrape_errors_total")
+	IsEqual(t, "metrics", nil, err)
+}
+
+func TestExporterPotsSharingName(t *testing.T) {
+	fake := &monzotest.Fake{
+		AccountsFunc: func() (model.Monzo, error) {
+			return model.Monzo{Accounts: []model.Account{{ID: "acc_1"}}}, nil
+		},
+		BalanceFunc: func(accountID string) (model.Balance, error) {
+			return model.Balance{Balance: 100, Currency: "GBP"}, nil
+		},
+		AccountPotsFunc: func(accountID string) ([]model.Pot, error) {
+			return []model.Pot{
+				{ID: "pot_1", Name: "Savings", Balance: 2500, Currency: "GBP"},
+				{ID: "pot_2", Name: "Savings", Balance: 1000, Currency: "GBP"},
+			}, nil
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	exporter, _ := prommonzo.NewExporter(func() (monzo.API, error) {
+		return fake, nil
+	}, registry)
+
+	err := exporter.Refresh()
+	IsEqual(t, "refresh error", nil, err)
+
+	expected := `
+# HELP monzo_pot_balance Balance of the pot in major currency units.
+# TYPE monzo_pot_balance gauge
+monzo_pot_balance{account="acc_1",currency="GBP",pot="Savings",pot_id="pot_1"} 25
+monzo_pot_balance{account="acc_1",currency="GBP",pot="Savings",pot_id="pot_2"} 10
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "monzo_pot_balance")
+	IsEqual(t, "metrics", nil, err)
+}
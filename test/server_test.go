@@ -0,0 +1,230 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+	"github.com/gurparit/go-monzo/monzotest"
+)
+
+func fakeMonzo() (*monzotest.Server, monzo.Monzo) {
+	server := monzotest.NewServer()
+	server.Install()
+
+	user := server.IssueToken()
+
+	return server, monzo.New(user.TokenType, user.AccessToken)
+}
+
+func TestServerLoginAndRefresh(t *testing.T) {
+	server := monzotest.NewServer()
+	server.Install()
+
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	redirectURI := monzo.RedirectURI
+	monzo.RedirectURI = "http://localhost/callback"
+
+	defer func() { monzo.RedirectURI = redirectURI }()
+
+	response, err := client.Get(monzo.Login("x-state"))
+	IsEqual(t, "login error", nil, err)
+	response.Body.Close()
+
+	redirect, _ := url.Parse(response.Header.Get("Location"))
+	IsEqual(t, "state", "x-state", redirect.Query().Get("state"))
+
+	user, err := monzo.Callback(redirect.Query().Get("code"))
+	IsEqual(t, "callback error", nil, err)
+
+	whoami, err := monzo.New(user.TokenType, user.AccessToken).WhoAmI()
+	IsEqual(t, "whoami error", nil, err)
+	IsEqual(t, "user id", monzotest.DefaultUserID, whoami.UserID)
+
+	_, err = monzo.Callback(redirect.Query().Get("code"))
+	IsEqual(t, "reused code", true, err != nil)
+
+	refreshed, err := monzo.Refresh(user.RefreshToken)
+	IsEqual(t, "refresh error", nil, err)
+
+	_, err = monzo.New(user.TokenType, user.AccessToken).WhoAmI()
+	IsEqual(t, "old token rejected", true, err != nil && strings.Contains(err.Error(), "unauthorized.bad_access_token"))
+
+	_, err = monzo.New(refreshed.TokenType, refreshed.AccessToken).WhoAmI()
+	IsEqual(t, "new token error", nil, err)
+
+	server.ExpireToken(refreshed.AccessToken)
+
+	err = monzo.New(refreshed.TokenType, refreshed.AccessToken).Logout()
+	IsEqual(t, "expired token", monzo.ErrTokenInvalid, err)
+}
+
+func TestServerPotTransfers(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+
+	updated, err := client.Deposit(pot.ID, account.ID, 2500)
+	IsEqual(t, "deposit error", nil, err)
+	IsEqual(t, "pot balance", int64(2500), updated.Balance)
+
+	updated, err = client.Withdraw(pot.ID, account.ID, 500)
+	IsEqual(t, "withdraw error", nil, err)
+	IsEqual(t, "pot balance after withdraw", int64(2000), updated.Balance)
+
+	balance, err := client.Balance(account.ID)
+	IsEqual(t, "balance error", nil, err)
+	IsEqual(t, "balance", int64(8000), balance.Balance)
+	IsEqual(t, "total balance", int64(10000), balance.TotalBalance)
+
+	_, err = client.Deposit(pot.ID, account.ID, 9000)
+	IsEqual(t, "insufficient funds", true, err != nil && strings.Contains(err.Error(), "forbidden.insufficient_funds"))
+
+	server.DeletePot(pot.ID)
+
+	_, err = client.Withdraw(pot.ID, account.ID, 100)
+	IsEqual(t, "deleted pot", true, err != nil && strings.Contains(err.Error(), "bad_request.pot_deleted"))
+}
+
+func TestServerDepositDedupe(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	user := server.IssueToken()
+
+	form := url.Values{
+		"source_account_id": {account.ID},
+		"amount":            {"1000"},
+		"dedupe_id":         {"x-dedupe-id"},
+	}
+
+	for i := 0; i < 2; i++ {
+		request, _ := http.NewRequest(http.MethodPut, monzo.GetURL(monzo.DepositURL, pot.ID), strings.NewReader(form.Encode()))
+		request.Header.Set("Authorization", "Bearer "+user.AccessToken)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		response, err := http.DefaultClient.Do(request)
+		IsEqual(t, "deposit error", nil, err)
+
+		var result model.Pot
+		json.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+
+		IsEqual(t, "pot balance", int64(1000), result.Balance)
+	}
+
+	balance, _ := monzo.New(user.TokenType, user.AccessToken).Balance(account.ID)
+	IsEqual(t, "account balance", int64(9000), balance.Balance)
+}
+
+func TestServerTransactionPagination(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		server.AddTransaction(model.TransactionData{
+			AccountID: account.ID,
+			Amount:    -100,
+			Created:   start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	first, err := client.Transactions(account.ID, monzo.TransactionParams{Limit: 2})
+	IsEqual(t, "first page error", nil, err)
+	IsEqual(t, "count(first page)", 2, len(first))
+
+	second, err := client.Transactions(account.ID, monzo.TransactionParams{SinceID: first[1].TransactionID, Limit: 2})
+	IsEqual(t, "second page error", nil, err)
+	IsEqual(t, "count(second page)", 2, len(second))
+	IsEqual(t, "second page start", start.Add(2*time.Hour), second[0].Created)
+
+	windowed, err := client.Transactions(account.ID, monzo.TransactionParams{
+		Since:  start.Add(time.Hour),
+		Before: start.Add(3 * time.Hour),
+	})
+	IsEqual(t, "windowed error", nil, err)
+	IsEqual(t, "count(windowed)", 2, len(windowed))
+
+	balance, _ := client.Balance(account.ID)
+	IsEqual(t, "balance", int64(9500), balance.Balance)
+	IsEqual(t, "spend today", int64(-500), balance.SpendToday)
+}
+
+func TestServerWebhookDelivery(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	delivered := make(chan model.Transaction, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event model.Transaction
+		json.NewDecoder(r.Body).Decode(&event)
+		delivered <- event
+	}))
+
+	defer receiver.Close()
+
+	webhookURI := monzo.WebhookURI
+	monzo.WebhookURI = receiver.URL
+
+	defer func() { monzo.WebhookURI = webhookURI }()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+
+	webhook, err := client.RegisterWebhook(account.ID)
+	IsEqual(t, "register error", nil, err)
+
+	webhooks, err := client.Webhooks(account.ID)
+	IsEqual(t, "webhooks error", nil, err)
+	IsEqual(t, "webhooks", []model.Webhook{webhook}, webhooks)
+
+	transaction := server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -350, Description: "Coffee"})
+
+	event := <-delivered
+	IsEqual(t, "type", "transaction.created", event.Type)
+	IsEqual(t, "transaction id", transaction.TransactionID, event.Data.TransactionID)
+	IsEqual(t, "currency", "GBP", event.Data.Currency)
+
+	err = client.DeleteWebhook(webhook.ID)
+	IsEqual(t, "delete error", nil, err)
+
+	server.AddTransaction(model.TransactionData{AccountID: account.ID, Amount: -100})
+	IsEqual(t, "count(deliveries)", 0, len(delivered))
+}
+
+func TestServerFeedItems(t *testing.T) {
+	server, client := fakeMonzo()
+
+	defer server.Close()
+
+	item := monzo.NewFeedItem("Saved!", "https://example.com/icon.png").WithBody("£25 into Holiday")
+
+	err := client.PostFeedItem("x-account-id", item)
+	IsEqual(t, "post error", nil, err)
+
+	items := server.FeedItems()
+	IsEqual(t, "count(items)", 1, len(items))
+	IsEqual(t, "title", "Saved!", items[0].Params["title"])
+	IsEqual(t, "body", "£25 into Holiday", items[0].Params["body"])
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestSlogRedactsSecrets(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	secret := monzo.ClientSecret
+	monzo.ClientSecret = "x-client-secret"
+	monzo.DefaultOptions = []monzo.Option{monzo.WithLogger(logger)}
+
+	defer func() {
+		monzo.ClientSecret = secret
+		monzo.DefaultOptions = nil
+	}()
+
+	account := server.AddAccount("Current", 10000, "GBP")
+	pot := server.AddPot(account.ID, "Holiday", 0)
+	issued := server.IssueToken()
+
+	user, err := monzo.Refresh(issued.RefreshToken)
+	IsEqual(t, "refresh error", nil, err)
+
+	client := monzo.New(user.TokenType, user.AccessToken)
+
+	_, err = client.Deposit(pot.ID, account.ID, 100)
+	IsEqual(t, "deposit error", nil, err)
+
+	logger.Debug("session", "user", user, "client", client)
+
+	content := output.String()
+	for _, secret := range []string{issued.RefreshToken, user.AccessToken, user.RefreshToken, "x-client-secret"} {
+		IsEqual(t, "leaked "+secret, false, strings.Contains(content, secret))
+	}
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	IsEqual(t, "count(lines)", 3, len(lines))
+
+	var entry map[string]interface{}
+	json.Unmarshal([]byte(lines[1]), &entry)
+
+	IsEqual(t, "level", "DEBUG", entry["level"])
+	IsEqual(t, "operation", "pots.deposit", entry["operation"])
+	IsEqual(t, "status", float64(200), entry["status"])
+	IsEqual(t, "request id", true, strings.HasPrefix(entry["request_id"].(string), "req_"))
+	IsEqual(t, "duration", true, entry["duration"] != nil)
+}
+
+func TestSlogSkipsWhenDisabled(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	user := server.IssueToken()
+	server.AddAccount("Current", 10000, "GBP")
+
+	_, err := monzo.New(user.TokenType, user.AccessToken, monzo.WithLogger(logger)).Accounts()
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "output", "", output.String())
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func snapshotServer(t *testing.T, inFlight *int, maxInFlight *int) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+
+		mu.Lock()
+		*inFlight++
+		if *inFlight > *maxInFlight {
+			*maxInFlight = *inFlight
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			*inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+
+		var data interface{}
+		switch r.URL.Path {
+		case "/accounts":
+			data = model.Monzo{Accounts: []model.Account{{ID: "x-account-1"}, {ID: "x-account-2"}}}
+		case "/balance":
+			if r.URL.Query().Get("account_id") == "x-account-2" {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"code": "internal_service"}`))
+				return
+			}
+
+			data = model.Balance{Balance: 10000, Currency: "GBP"}
+		case "/pots":
+			accountID := r.URL.Query().Get("current_account_id")
+			data = model.Monzo{Pots: []model.Pot{
+				{ID: accountID + "-pot", Balance: 2500, Currency: "GBP", CurrentAccountID: accountID},
+				{ID: accountID + "-deleted", Balance: 9999, Currency: "GBP", Deleted: true},
+			}}
+		default:
+			t.Logf("unexpected path %s", r.URL.Path)
+			t.Fail()
+		}
+
+		response, _ := json.Marshal(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+}
+
+func TestSnapshot(t *testing.T) {
+	var inFlight, maxInFlight int
+	testHttp := snapshotServer(t, &inFlight, &maxInFlight)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL+"/accounts")
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"/balance?account_id=%s")
+	monzo.SetURL(monzo.AccountPotsURL, testHttp.URL+"/pots?current_account_id=%s")
+
+	monzo.SnapshotConcurrency = 2
+	defer func() { monzo.SnapshotConcurrency = 4 }()
+
+	snapshot, err := monzo.New("Bearer", "x-access-token").Snapshot(context.Background())
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "taken", true, time.Since(snapshot.Taken) < time.Minute)
+	IsEqual(t, "count(accounts)", 2, len(snapshot.Accounts))
+	IsEqual(t, "max in flight", true, maxInFlight <= 2)
+
+	first := snapshot.Accounts[0]
+	IsEqual(t, "first.account.id", "x-account-1", first.Account.ID)
+	IsEqual(t, "first.error", nil, first.Err)
+	IsEqual(t, "first.balance", model.NewMoney(10000, "GBP"), first.Balance.Money())
+	IsEqual(t, "first.pots", 2, len(first.Pots))
+	IsEqual(t, "first.pots_total", []model.Money{model.NewMoney(2500, "GBP")}, first.PotsTotal())
+
+	second := snapshot.Accounts[1]
+	IsEqual(t, "second.account.id", "x-account-2", second.Account.ID)
+	IsEqual(t, "second.error", true, second.Err != nil && strings.Contains(second.Err.Error(), "internal_service"))
+
+	IsEqual(t, "count(failed)", 1, len(snapshot.Failed()))
+	IsEqual(t, "net worth", []model.Money{model.NewMoney(12500, "GBP")}, snapshot.NetWorth())
+}
+
+func TestSnapshotCancelledFail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := monzo.New("Bearer", "x-access-token").Snapshot(ctx)
+	IsEqual(t, "error", context.Canceled, err)
+}
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func TestLogoutSuccess(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Test Headers
+		IsEqual(t, "Method", http.MethodPost, r.Method)
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.LogoutURL, testHttp.URL)
+
+	store := monzo.NewMemoryTokenStore(model.User{AccessToken: "x-access-token"})
+
+	err := monzo.New("Bearer", "x-access-token").LogoutAndForget(store)
+	IsEqual(t, "error", nil, err)
+
+	_, err = store.Load()
+	IsEqual(t, "load error", monzo.ErrNoToken, err)
+}
+
+func TestLogoutTokenInvalidFail(t *testing.T) {
+	badAccessToken := `
+{
+	"code": "unauthorized.bad_access_token.evicted",
+	"message": "Access token has been evicted"
+}
+`
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(badAccessToken))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.LogoutURL, testHttp.URL)
+
+	err := monzo.New("Bearer", "x-access-token").Logout()
+	IsEqual(t, "error", monzo.ErrTokenInvalid, err)
+
+	store := monzo.NewMemoryTokenStore(model.User{AccessToken: "x-access-token"})
+
+	err = monzo.New("Bearer", "x-access-token").LogoutAndForget(store)
+	IsEqual(t, "purge error", monzo.ErrTokenInvalid, err)
+
+	_, err = store.Load()
+	IsEqual(t, "load error", monzo.ErrNoToken, err)
+}
+
+func TestLogoutServerErrorKeepsToken(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code": "internal_service"}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.LogoutURL, testHttp.URL)
+
+	store := monzo.NewMemoryTokenStore(model.User{AccessToken: "x-access-token"})
+
+	err := monzo.New("Bearer", "x-access-token").LogoutAndForget(store)
+	IsEqual(t, "has error", true, err != nil)
+
+	user, err := store.Load()
+	IsEqual(t, "load error", nil, err)
+	IsEqual(t, "user.access_token", "x-access-token", user.AccessToken)
+}
+
+func TestRefreshNonConfidentialFail(t *testing.T) {
+	monzo.Confidential = false
+	defer func() { monzo.Confidential = true }()
+
+	_, err := monzo.Refresh("x-refresh-token")
+	IsEqual(t, "error", monzo.ErrRefreshUnsupported, err)
+
+	user := model.User{RefreshToken: "x-refresh-token"}
+	IsEqual(t, "can refresh", false, monzo.CanRefresh(user))
+}
+
+func TestTokenSourceRefreshesExpiringToken(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Test Form
+		IsEqual(t, "grant_type", "refresh_token", r.PostFormValue("grant_type"))
+		IsEqual(t, "refresh_token", "x-refresh-token", r.PostFormValue("refresh_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "new-x-access-token", "refresh_token": "new-x-refresh-token", "token_type": "Bearer", "expires_in": 21600}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	store := monzo.NewMemoryTokenStore(model.User{
+		AccessToken:  "x-access-token",
+		RefreshToken: "x-refresh-token",
+		TokenType:    "Bearer",
+		ExpiryDate:   time.Now().Add(10 * time.Second),
+	})
+
+	source := monzo.NewTokenSource(store)
+	IsEqual(t, "can refresh", true, source.CanRefresh())
+
+	user, err := source.Token()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "user.access_token", "new-x-access-token", user.AccessToken)
+
+	stored, _ := store.Load()
+	IsEqual(t, "stored.refresh_token", "new-x-refresh-token", stored.RefreshToken)
+}
+
+func TestTokenSourceRefreshUsesOptions(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "new-x-access-token", "refresh_token": "new-x-refresh-token", "token_type": "Bearer", "expires_in": 21600}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	store := monzo.NewMemoryTokenStore(model.User{
+		AccessToken:  "x-access-token",
+		RefreshToken: "x-refresh-token",
+		TokenType:    "Bearer",
+		ExpiryDate:   time.Now().Add(10 * time.Second),
+	})
+
+	var operations []string
+	source := monzo.NewTokenSource(store)
+	source.Options = []monzo.Option{monzo.WithMiddleware(func(next monzo.Doer) monzo.Doer {
+		return func(call *monzo.Call) (*http.Response, error) {
+			operations = append(operations, call.Operation)
+			return next(call)
+		}
+	})}
+
+	_, err := source.Token()
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "operations", []string{monzo.OpTokenRefresh}, operations)
+}
+
+func TestTokenSourceValidTokenUnchanged(t *testing.T) {
+	store := monzo.NewMemoryTokenStore(model.User{
+		AccessToken: "x-access-token",
+		TokenType:   "Bearer",
+		ExpiryDate:  time.Now().Add(time.Hour),
+	})
+
+	user, err := monzo.NewTokenSource(store).Token()
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "user.access_token", "x-access-token", user.AccessToken)
+}
+
+func TestTokenSourceNonConfidentialExpiredFail(t *testing.T) {
+	monzo.Confidential = false
+	defer func() { monzo.Confidential = true }()
+
+	store := monzo.NewMemoryTokenStore(model.User{
+		AccessToken:  "x-access-token",
+		RefreshToken: "x-refresh-token",
+		ExpiryDate:   time.Now().Add(-time.Minute),
+	})
+
+	source := monzo.NewTokenSource(store)
+	IsEqual(t, "can refresh", false, source.CanRefresh())
+
+	_, err := source.Token()
+	IsEqual(t, "error", monzo.ErrLoginRequired, err)
+}
+
+func TestCallbackWithoutClientSecret(t *testing.T) {
+	secret := monzo.ClientSecret
+	monzo.ClientSecret = ""
+	defer func() { monzo.ClientSecret = secret }()
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		_, hasSecret := r.PostForm["client_secret"]
+		IsEqual(t, "has client_secret", false, hasSecret)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "x-access-token", "token_type": "Bearer", "expires_in": 21600}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.Oauth2URL, testHttp.URL)
+
+	user, err := monzo.Callback("x-code")
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "user.refresh_token", "", user.RefreshToken)
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+type countingTransport struct {
+	calls int32
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestClientResponseMetadata(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEqual(t, "Accept", "application/json", r.Header.Get("Accept"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "x-request-id")
+		w.Write([]byte(`{"balance": 5000, "currency": "GBP"}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"?account_id=%s")
+
+	transport := &countingTransport{}
+	client := monzo.New("Bearer", "x-access-token", monzo.WithHTTPClient(&http.Client{Transport: transport}))
+
+	var recorder monzo.ResponseRecorder
+	balance, err := client.WithResponse(&recorder).Balance("x-account-id")
+	IsEqual(t, "error", nil, err)
+	IsEqual(t, "balance", int64(5000), balance.Balance)
+
+	response := recorder.Last()
+	IsEqual(t, "status", http.StatusOK, response.StatusCode)
+	IsEqual(t, "request id", "x-request-id", response.RequestID)
+	IsEqual(t, "content type", "application/json", response.Header.Get("Content-Type"))
+	IsEqual(t, "transport calls", int32(1), atomic.LoadInt32(&transport.calls))
+}
+
+func TestClientErrorMetadata(t *testing.T) {
+	body := `{"code": "forbidden.insufficient_funds", "message": "Not enough money"}`
+
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "x-request-id")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.DepositURL, testHttp.URL+"/%s")
+
+	_, err := monzo.New("Bearer", "x-access-token").Deposit("x-pot-id", "x-account-id", 100)
+
+	apiErr, ok := err.(*monzo.Error)
+	IsEqual(t, "error type", true, ok)
+	IsEqual(t, "status", http.StatusForbidden, apiErr.StatusCode)
+	IsEqual(t, "request id", "x-request-id", apiErr.RequestID)
+	IsEqual(t, "code", "forbidden.insufficient_funds", apiErr.Code)
+	IsEqual(t, "message", "Not enough money", apiErr.Message)
+	IsEqual(t, "error string", body, err.Error())
+}
+
+func TestClientResponseTooLarge(t *testing.T) {
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"accounts": [], "padding": "` + strings.Repeat("x", 256) + `"}`))
+	}))
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL)
+
+	limit := monzo.MaxResponseSize
+	monzo.MaxResponseSize = 128
+
+	defer func() { monzo.MaxResponseSize = limit }()
+
+	_, err := monzo.New("Bearer", "x-access-token").Accounts()
+	IsEqual(t, "error", monzo.ErrResponseTooLarge, err)
+}
+
+func TestClientTransportError(t *testing.T) {
+	testHttp := httptest.NewServer(http.NotFoundHandler())
+	testHttp.Close()
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL)
+
+	_, err := monzo.New("Bearer", "x-access-token").Accounts()
+	IsEqual(t, "error", true, err != nil)
+}
+
+func TestClientResponseRecorderConcurrent(t *testing.T) {
+	server, _ := fakeMonzo()
+
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		server.AddAccount("Current", 1000, "GBP")
+	}
+
+	user := server.IssueToken()
+
+	var recorder monzo.ResponseRecorder
+	client := monzo.New(user.TokenType, user.AccessToken).WithResponse(&recorder)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Snapshot(context.Background())
+		}()
+	}
+
+	wg.Wait()
+
+	IsEqual(t, "status", http.StatusOK, recorder.Last().StatusCode)
+}
+
+func TestClientContextCancelsRequest(t *testing.T) {
+	release := make(chan struct{})
+	testHttp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+
+	defer testHttp.Close()
+	defer close(release)
+
+	monzo.SetURL(monzo.AccountsURL, testHttp.URL+"/accounts")
+	monzo.SetURL(monzo.BalanceURL, testHttp.URL+"/balance?account_id=%s")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := monzo.New("Bearer", "x-access-token").WithContext(ctx).Balance("x-account-id")
+	IsEqual(t, "deadline exceeded", true, errors.Is(err, context.DeadlineExceeded))
+	IsEqual(t, "returned promptly", true, time.Since(started) < time.Second)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started = time.Now()
+	_, err = monzo.New("Bearer", "x-access-token").Snapshot(ctx)
+	IsEqual(t, "snapshot error", true, err != nil)
+	IsEqual(t, "snapshot returned promptly", true, time.Since(started) < time.Second)
+}
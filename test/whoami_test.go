@@ -0,0 +1,103 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gurparit/go-monzo/model"
+	"github.com/gurparit/go-monzo/monzo"
+)
+
+func whoAmIServer(t *testing.T, authenticated bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Test Headers
+		IsEqual(t, "Method", http.MethodGet, r.Method)
+		IsEqual(t, "Authorization", "Bearer x-access-token", r.Header.Get("Authorization"))
+		IsEqual(t, "Content-Type", "", r.Header.Get("Content-Type"))
+
+		response, err := json.Marshal(model.WhoAmI{
+			Authenticated: authenticated,
+			ClientID:      "x-client-id",
+			UserID:        "x-user-id",
+		})
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+}
+
+func TestWhoAmI(t *testing.T) {
+	testHttp := whoAmIServer(t, true)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.WhoAmIURL, testHttp.URL)
+
+	whoami, err := monzo.New("Bearer", "x-access-token").WhoAmI()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "authenticated", true, whoami.Authenticated)
+	IsEqual(t, "client_id", "x-client-id", whoami.ClientID)
+	IsEqual(t, "user_id", "x-user-id", whoami.UserID)
+}
+
+func TestTokenInfo(t *testing.T) {
+	testHttp := whoAmIServer(t, true)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.WhoAmIURL, testHttp.URL)
+
+	user := model.User{
+		AccessToken:  "x-access-token",
+		RefreshToken: "x-refresh-token",
+		ExpiryDate:   time.Now().Add(time.Hour),
+	}
+
+	info, err := monzo.New("Bearer", "x-access-token").TokenInfo(user)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "user_id", "x-user-id", info.UserID)
+	IsEqual(t, "expiry_date", user.ExpiryDate, info.ExpiryDate)
+	IsEqual(t, "can_refresh", true, info.CanRefresh)
+	IsEqual(t, "remaining", true, info.Remaining > 59*time.Minute && info.Remaining <= time.Hour)
+	IsEqual(t, "expired", false, info.Expired())
+	IsEqual(t, "expires within 2h", true, info.ExpiresWithin(2*time.Hour))
+	IsEqual(t, "expires within 30m", false, info.ExpiresWithin(30*time.Minute))
+}
+
+func TestTokenInfoExpired(t *testing.T) {
+	testHttp := whoAmIServer(t, false)
+
+	defer testHttp.Close()
+
+	monzo.SetURL(monzo.WhoAmIURL, testHttp.URL)
+
+	user := model.User{
+		AccessToken: "x-access-token",
+		ExpiryDate:  time.Now().Add(-time.Minute),
+	}
+
+	info, err := monzo.New("Bearer", "x-access-token").TokenInfo(user)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	IsEqual(t, "remaining", time.Duration(0), info.Remaining)
+	IsEqual(t, "can_refresh", false, info.CanRefresh)
+	IsEqual(t, "expired", true, info.Expired())
+}